@@ -38,6 +38,11 @@ import (
 //      - merge entries with the same Key() that are in the same list
 //      - remove entries from the parent with the same Key() that are not in the same list
 //      - append entries that are unique to the child
+//   g) List entries in the child which delete inherited entries (e.g. ignition.storage.files[i].deleteInherited):
+//      - remove entries from the parent with the same Key(), in any list merged with the same handle
+//      - are kept in the result, so they also apply when the result is merged into a further parent
+//      entries in the parent which delete inherited entries are kept, unless replaced by a child entry
+//      StripDeletionsTranscribe() removes the remaining ones once there's no further parent
 
 const (
 	TAG_PARENT = "parent"
@@ -56,8 +61,20 @@ func (m Mapping) String() string {
 	return fmt.Sprintf("%s:%s → %s", m.From.Tag, m.From, m.To)
 }
 
+// A parent list entry deleted by a child entry which deletes inherited
+// entries.  Parent.Tag will be TAG_PARENT and Child.Tag will be TAG_CHILD.
+type Deletion struct {
+	Parent path.ContextPath
+	Child  path.ContextPath
+}
+
+func (d Deletion) String() string {
+	return fmt.Sprintf("%s:%s deleted by %s:%s", d.Parent.Tag, d.Parent, d.Child.Tag, d.Child)
+}
+
 type Transcript struct {
-	Mappings []Mapping
+	Mappings  []Mapping
+	Deletions []Deletion
 }
 
 func (t Transcript) String() string {
@@ -65,6 +82,9 @@ func (t Transcript) String() string {
 	for _, m := range t.Mappings {
 		lines = append(lines, m.String())
 	}
+	for _, d := range t.Deletions {
+		lines = append(lines, d.String())
+	}
 	return strings.Join(lines, "\n")
 }
 
@@ -210,6 +230,65 @@ func MergeStructTranscribe(parent, child interface{}) (interface{}, Transcript)
 	return result.Interface(), transcript
 }
 
+// StripDeletionsTranscribe is intended for use by config/vX_Y/ packages.
+//
+// StripDeletionsTranscribe returns a copy of the config without the list
+// entries which delete inherited entries, for use once the config won't be
+// merged into a further parent, and a transcript of the fields kept. The
+// paths of the config are tagged TAG_CHILD.
+func StripDeletionsTranscribe(config interface{}) (interface{}, Transcript) {
+	var transcript Transcript
+	result := stripDeletions(reflect.ValueOf(config), path.New(TAG_CHILD), path.New(TAG_RESULT), &transcript)
+	return result.Interface(), transcript
+}
+
+func stripDeletions(value reflect.Value, fromPath path.ContextPath, toPath path.ContextPath, transcript *Transcript) reflect.Value {
+	result := reflect.New(value.Type()).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		fieldMeta := value.Type().Field(i)
+		field := value.Field(i)
+		resultField := result.Field(i)
+		fromFieldPath := pathAppendField(fromPath, fieldMeta)
+		toFieldPath := pathAppendField(toPath, fieldMeta)
+
+		switch {
+		case field.Kind() == reflect.Struct:
+			before := len(transcript.Mappings)
+			resultField.Set(stripDeletions(field, fromFieldPath, toFieldPath, transcript))
+			// embedded structs and empty structs should be invisible
+			if len(transcript.Mappings) > before && !fieldMeta.Anonymous {
+				transcribeOne(fromFieldPath, toFieldPath, transcript)
+			}
+		case field.Kind() == reflect.Slice && hasDeletions(field):
+			resultField.Set(reflect.MakeSlice(field.Type(), 0, field.Len()))
+			for j := 0; j < field.Len(); j++ {
+				item := field.Index(j)
+				if util.CallDeletesInherited(item) {
+					continue
+				}
+				appendToSlice(resultField, item)
+				transcribe(fromFieldPath.Append(j), toFieldPath.Append(resultField.Len()-1), item, fieldMeta, transcript)
+			}
+			if resultField.Len() > 0 {
+				transcribeOne(fromFieldPath, toFieldPath, transcript)
+			}
+		default:
+			resultField.Set(field)
+			transcribe(fromFieldPath, toFieldPath, field, fieldMeta, transcript)
+		}
+	}
+	return result
+}
+
+func hasDeletions(list reflect.Value) bool {
+	for i := 0; i < list.Len(); i++ {
+		if util.CallDeletesInherited(list.Index(i)) {
+			return true
+		}
+	}
+	return false
+}
+
 // parent and child MUST be the same type
 // the transcript lists children before parents
 // all interior nodes that have contributions from both parent and child
@@ -298,7 +377,22 @@ func mergeStruct(parent reflect.Value, parentPath path.ContextPath, child reflec
 				key := util.CallKey(parentItem)
 
 				if childItem, childList, childListIndex, ok := info.getChildEntryByKey(fieldMeta.Name, key); ok {
-					if childList == fieldMeta.Name {
+					if util.CallDeletesInherited(childItem) {
+						// case 0: deleted by child config, in any list
+						if !util.CallDeletesInherited(parentItem) {
+							childListMeta, _ := child.Type().FieldByName(childList)
+							transcribeDeletion(parentItemPath, pathAppendField(childPath, childListMeta).Append(childListIndex), transcript)
+						}
+						// keep deleting it from the parent's parents. The child entry
+						// replaces the parent one; if it's in a different list, it'll be
+						// appended when iterating over that list.
+						if childList == fieldMeta.Name {
+							childItemPath := childFieldPath.Append(childListIndex)
+							appendToSlice(resultField, childItem)
+							transcribe(childItemPath, resultItemPath, childItem, fieldMeta, transcript)
+							itemFromChild = true
+						}
+					} else if childList == fieldMeta.Name {
 						// case 1: in child config in same list
 						childItemPath := childFieldPath.Append(childListIndex)
 						// record the contribution of both parent and child, even if one wins
@@ -311,6 +405,13 @@ func mergeStruct(parent reflect.Value, parentPath path.ContextPath, child reflec
 							if fieldMeta.Name == "HTTPHeaders" && childItem.FieldByName("Value").IsNil() {
 								continue
 							}
+							// If the parent entry only deletes an inherited entry, the child
+							// entry replaces it.
+							if util.CallDeletesInherited(parentItem) {
+								appendToSlice(resultField, childItem)
+								transcribe(childItemPath, resultItemPath, childItem, fieldMeta, transcript)
+								continue
+							}
 							appendToSlice(resultField, mergeStruct(parentItem, parentItemPath, childItem, childItemPath, resultItemPath, transcript))
 							transcribeOne(parentItemPath, resultItemPath, transcript)
 							transcribeOne(childItemPath, resultItemPath, transcript)
@@ -416,6 +517,14 @@ func transcribeOne(from, to path.ContextPath, transcript *Transcript) {
 	})
 }
 
+// transcribeDeletion records one Deletion into a Transcript.
+func transcribeDeletion(parent, child path.ContextPath, transcript *Transcript) {
+	transcript.Deletions = append(transcript.Deletions, Deletion{
+		Parent: parent.Copy(),
+		Child:  child.Copy(),
+	})
+}
+
 // getKeySet takes a value of a slice and returns the set of all the Key() values in that slice
 func getKeySet(list reflect.Value) map[string]struct{} {
 	m := map[string]struct{}{}
@@ -53,7 +53,7 @@ func TestMerge(t *testing.T) {
 			out: types.Config{
 				Ignition: types.Ignition{Version: "haha this isn't validated"},
 			},
-			transcript: Transcript{[]Mapping{
+			transcript: Transcript{Mappings: []Mapping{
 				{path.New(TAG_CHILD, "ignition", "version"), path.New(TAG_RESULT, "ignition", "version")},
 				{path.New(TAG_PARENT, "ignition"), path.New(TAG_RESULT, "ignition")},
 				{path.New(TAG_CHILD, "ignition"), path.New(TAG_RESULT, "ignition")},
@@ -260,7 +260,7 @@ func TestMerge(t *testing.T) {
 					},
 				},
 			},
-			transcript: Transcript{[]Mapping{
+			transcript: Transcript{Mappings: []Mapping{
 				{path.New(TAG_CHILD, "passwd", "users", 0, "name"), path.New(TAG_RESULT, "passwd", "users", 0, "name")},
 				{path.New(TAG_PARENT, "passwd", "users", 0, "sshAuthorizedKeys", 0), path.New(TAG_RESULT, "passwd", "users", 0, "sshAuthorizedKeys", 0)},
 				{path.New(TAG_CHILD, "passwd", "users", 0, "sshAuthorizedKeys", 1), path.New(TAG_RESULT, "passwd", "users", 0, "sshAuthorizedKeys", 1)},
@@ -396,7 +396,7 @@ func TestMerge(t *testing.T) {
 					},
 				},
 			},
-			transcript: Transcript{[]Mapping{
+			transcript: Transcript{Mappings: []Mapping{
 				{path.New(TAG_PARENT, "ignition", "config", "merge", 0, "httpHeaders", 0, "name"), path.New(TAG_RESULT, "ignition", "config", "merge", 0, "httpHeaders", 0, "name")},
 				{path.New(TAG_PARENT, "ignition", "config", "merge", 0, "httpHeaders", 0, "value"), path.New(TAG_RESULT, "ignition", "config", "merge", 0, "httpHeaders", 0, "value")},
 				{path.New(TAG_PARENT, "ignition", "config", "merge", 0, "httpHeaders", 0), path.New(TAG_RESULT, "ignition", "config", "merge", 0, "httpHeaders", 0)},
@@ -491,7 +491,7 @@ func TestMerge(t *testing.T) {
 					},
 				},
 			},
-			transcript: Transcript{[]Mapping{
+			transcript: Transcript{Mappings: []Mapping{
 				{path.New(TAG_PARENT, "ignition", "config", "replace", "httpHeaders", 0, "name"), path.New(TAG_RESULT, "ignition", "config", "replace", "httpHeaders", 0, "name")},
 				{path.New(TAG_PARENT, "ignition", "config", "replace", "httpHeaders", 0, "value"), path.New(TAG_RESULT, "ignition", "config", "replace", "httpHeaders", 0, "value")},
 				{path.New(TAG_PARENT, "ignition", "config", "replace", "httpHeaders", 0), path.New(TAG_RESULT, "ignition", "config", "replace", "httpHeaders", 0)},
@@ -596,7 +596,7 @@ func TestMerge(t *testing.T) {
 					},
 				},
 			},
-			transcript: Transcript{[]Mapping{
+			transcript: Transcript{Mappings: []Mapping{
 				{path.New(TAG_PARENT, "ignition", "security", "tls", "certificateAuthorities", 0, "httpHeaders", 0, "name"), path.New(TAG_RESULT, "ignition", "security", "tls", "certificateAuthorities", 0, "httpHeaders", 0, "name")},
 				{path.New(TAG_PARENT, "ignition", "security", "tls", "certificateAuthorities", 0, "httpHeaders", 0, "value"), path.New(TAG_RESULT, "ignition", "security", "tls", "certificateAuthorities", 0, "httpHeaders", 0, "value")},
 				{path.New(TAG_PARENT, "ignition", "security", "tls", "certificateAuthorities", 0, "httpHeaders", 0), path.New(TAG_RESULT, "ignition", "security", "tls", "certificateAuthorities", 0, "httpHeaders", 0)},
@@ -705,7 +705,7 @@ func TestMerge(t *testing.T) {
 					},
 				},
 			},
-			transcript: Transcript{[]Mapping{
+			transcript: Transcript{Mappings: []Mapping{
 				{path.New(TAG_PARENT, "storage", "files", 0, "contents", "httpHeaders", 0, "name"), path.New(TAG_RESULT, "storage", "files", 0, "contents", "httpHeaders", 0, "name")},
 				{path.New(TAG_PARENT, "storage", "files", 0, "contents", "httpHeaders", 0, "value"), path.New(TAG_RESULT, "storage", "files", 0, "contents", "httpHeaders", 0, "value")},
 				{path.New(TAG_PARENT, "storage", "files", 0, "contents", "httpHeaders", 0), path.New(TAG_RESULT, "storage", "files", 0, "contents", "httpHeaders", 0)},
@@ -820,7 +820,7 @@ func TestMerge(t *testing.T) {
 					},
 				},
 			},
-			transcript: Transcript{[]Mapping{
+			transcript: Transcript{Mappings: []Mapping{
 				{path.New(TAG_PARENT, "storage", "files", 0, "append", 0, "httpHeaders", 0, "name"), path.New(TAG_RESULT, "storage", "files", 0, "append", 0, "httpHeaders", 0, "name")},
 				{path.New(TAG_PARENT, "storage", "files", 0, "append", 0, "httpHeaders", 0, "value"), path.New(TAG_RESULT, "storage", "files", 0, "append", 0, "httpHeaders", 0, "value")},
 				{path.New(TAG_PARENT, "storage", "files", 0, "append", 0, "httpHeaders", 0), path.New(TAG_RESULT, "storage", "files", 0, "append", 0, "httpHeaders", 0)},
@@ -1018,7 +1018,7 @@ func TestMerge(t *testing.T) {
 					},
 				},
 			},
-			transcript: Transcript{[]Mapping{
+			transcript: Transcript{Mappings: []Mapping{
 				{path.New(TAG_PARENT, "storage", "directories", 0, "path"), path.New(TAG_RESULT, "storage", "directories", 0, "path")},
 				{path.New(TAG_PARENT, "storage", "directories", 0), path.New(TAG_RESULT, "storage", "directories", 0)},
 				{path.New(TAG_PARENT, "storage", "directories"), path.New(TAG_RESULT, "storage", "directories")},
@@ -1156,7 +1156,7 @@ func TestMerge(t *testing.T) {
 					},
 				},
 			},
-			transcript: Transcript{[]Mapping{
+			transcript: Transcript{Mappings: []Mapping{
 				{path.New(TAG_PARENT, "storage", "files", 0, "path"), path.New(TAG_RESULT, "storage", "files", 0, "path")},
 				{path.New(TAG_PARENT, "storage", "files", 0, "append", 0, "httpHeaders", 0, "name"), path.New(TAG_RESULT, "storage", "files", 0, "append", 0, "httpHeaders", 0, "name")},
 				{path.New(TAG_PARENT, "storage", "files", 0, "append", 0, "httpHeaders", 0, "value"), path.New(TAG_RESULT, "storage", "files", 0, "append", 0, "httpHeaders", 0, "value")},
@@ -1267,7 +1267,7 @@ func TestMerge(t *testing.T) {
 					},
 				},
 			},
-			transcript: Transcript{[]Mapping{
+			transcript: Transcript{Mappings: []Mapping{
 				{path.New(TAG_CHILD, "storage", "files", 0, "path"), path.New(TAG_RESULT, "storage", "files", 0, "path")},
 				{path.New(TAG_CHILD, "storage", "files", 0, "append", 0, "httpHeaders", 0, "name"), path.New(TAG_RESULT, "storage", "files", 0, "append", 0, "httpHeaders", 0, "name")},
 				{path.New(TAG_CHILD, "storage", "files", 0, "append", 0, "httpHeaders", 0, "value"), path.New(TAG_RESULT, "storage", "files", 0, "append", 0, "httpHeaders", 0, "value")},
@@ -1326,7 +1326,7 @@ func TestMerge(t *testing.T) {
 					},
 				},
 			},
-			transcript: Transcript{[]Mapping{
+			transcript: Transcript{Mappings: []Mapping{
 				{path.New(TAG_PARENT, "kernelArguments", "shouldExist", 0), path.New(TAG_RESULT, "kernelArguments", "shouldExist", 0)},
 				{path.New(TAG_PARENT, "kernelArguments", "shouldExist", 1), path.New(TAG_RESULT, "kernelArguments", "shouldExist", 1)},
 				{path.New(TAG_PARENT, "kernelArguments", "shouldExist"), path.New(TAG_RESULT, "kernelArguments", "shouldExist")},
@@ -1338,6 +1338,117 @@ func TestMerge(t *testing.T) {
 				{path.New(TAG_CHILD, "kernelArguments"), path.New(TAG_RESULT, "kernelArguments")},
 			}},
 		},
+		{
+			// deleting inherited entries, in the same list and across lists;
+			// the deletions are kept, so they also apply to further parents
+			in1: types.Config{
+				Passwd: types.Passwd{
+					Users: []types.PasswdUser{
+						{Name: "core", Shell: util.StrToPtr("/bin/bash")},
+						{Name: "admin", DeleteInherited: util.BoolToPtr(true)},
+					},
+				},
+				Storage: types.Storage{
+					Files: []types.File{
+						{Node: types.Node{Path: "/a"}},
+						{Node: types.Node{Path: "/b"}},
+						{Node: types.Node{Path: "/c"}},
+					},
+				},
+				Systemd: types.Systemd{
+					Units: []types.Unit{
+						{Name: "a.service", Enabled: util.BoolToPtr(true)},
+					},
+				},
+			},
+			in2: types.Config{
+				Passwd: types.Passwd{
+					Users: []types.PasswdUser{
+						{Name: "core", DeleteInherited: util.BoolToPtr(true)},
+						{Name: "admin", Shell: util.StrToPtr("/bin/sh")},
+						{Name: "nobody", DeleteInherited: util.BoolToPtr(true)},
+					},
+				},
+				Storage: types.Storage{
+					Files: []types.File{
+						{Node: types.Node{Path: "/a", DeleteInherited: util.BoolToPtr(true)}},
+					},
+					Links: []types.Link{
+						{Node: types.Node{Path: "/b", DeleteInherited: util.BoolToPtr(true)}},
+					},
+				},
+				Systemd: types.Systemd{
+					Units: []types.Unit{
+						{Name: "a.service", DeleteInherited: util.BoolToPtr(true)},
+					},
+				},
+			},
+			out: types.Config{
+				Passwd: types.Passwd{
+					Users: []types.PasswdUser{
+						{Name: "core", DeleteInherited: util.BoolToPtr(true)},
+						{Name: "admin", Shell: util.StrToPtr("/bin/sh")},
+						{Name: "nobody", DeleteInherited: util.BoolToPtr(true)},
+					},
+				},
+				Storage: types.Storage{
+					Files: []types.File{
+						{Node: types.Node{Path: "/a", DeleteInherited: util.BoolToPtr(true)}},
+						{Node: types.Node{Path: "/c"}},
+					},
+					Links: []types.Link{
+						{Node: types.Node{Path: "/b", DeleteInherited: util.BoolToPtr(true)}},
+					},
+				},
+				Systemd: types.Systemd{
+					Units: []types.Unit{
+						{Name: "a.service", DeleteInherited: util.BoolToPtr(true)},
+					},
+				},
+			},
+			transcript: Transcript{
+				Mappings: []Mapping{
+					{path.New(TAG_CHILD, "passwd", "users", 0, "deleteInherited"), path.New(TAG_RESULT, "passwd", "users", 0, "deleteInherited")},
+					{path.New(TAG_CHILD, "passwd", "users", 0, "name"), path.New(TAG_RESULT, "passwd", "users", 0, "name")},
+					{path.New(TAG_CHILD, "passwd", "users", 0), path.New(TAG_RESULT, "passwd", "users", 0)},
+					{path.New(TAG_CHILD, "passwd", "users", 1, "name"), path.New(TAG_RESULT, "passwd", "users", 1, "name")},
+					{path.New(TAG_CHILD, "passwd", "users", 1, "shell"), path.New(TAG_RESULT, "passwd", "users", 1, "shell")},
+					{path.New(TAG_CHILD, "passwd", "users", 1), path.New(TAG_RESULT, "passwd", "users", 1)},
+					{path.New(TAG_CHILD, "passwd", "users", 2, "deleteInherited"), path.New(TAG_RESULT, "passwd", "users", 2, "deleteInherited")},
+					{path.New(TAG_CHILD, "passwd", "users", 2, "name"), path.New(TAG_RESULT, "passwd", "users", 2, "name")},
+					{path.New(TAG_CHILD, "passwd", "users", 2), path.New(TAG_RESULT, "passwd", "users", 2)},
+					{path.New(TAG_PARENT, "passwd", "users"), path.New(TAG_RESULT, "passwd", "users")},
+					{path.New(TAG_CHILD, "passwd", "users"), path.New(TAG_RESULT, "passwd", "users")},
+					{path.New(TAG_PARENT, "passwd"), path.New(TAG_RESULT, "passwd")},
+					{path.New(TAG_CHILD, "passwd"), path.New(TAG_RESULT, "passwd")},
+					{path.New(TAG_CHILD, "storage", "files", 0, "deleteInherited"), path.New(TAG_RESULT, "storage", "files", 0, "deleteInherited")},
+					{path.New(TAG_CHILD, "storage", "files", 0, "path"), path.New(TAG_RESULT, "storage", "files", 0, "path")},
+					{path.New(TAG_CHILD, "storage", "files", 0), path.New(TAG_RESULT, "storage", "files", 0)},
+					{path.New(TAG_PARENT, "storage", "files", 2, "path"), path.New(TAG_RESULT, "storage", "files", 1, "path")},
+					{path.New(TAG_PARENT, "storage", "files", 2), path.New(TAG_RESULT, "storage", "files", 1)},
+					{path.New(TAG_PARENT, "storage", "files"), path.New(TAG_RESULT, "storage", "files")},
+					{path.New(TAG_CHILD, "storage", "files"), path.New(TAG_RESULT, "storage", "files")},
+					{path.New(TAG_CHILD, "storage", "links", 0, "deleteInherited"), path.New(TAG_RESULT, "storage", "links", 0, "deleteInherited")},
+					{path.New(TAG_CHILD, "storage", "links", 0, "path"), path.New(TAG_RESULT, "storage", "links", 0, "path")},
+					{path.New(TAG_CHILD, "storage", "links", 0), path.New(TAG_RESULT, "storage", "links", 0)},
+					{path.New(TAG_CHILD, "storage", "links"), path.New(TAG_RESULT, "storage", "links")},
+					{path.New(TAG_PARENT, "storage"), path.New(TAG_RESULT, "storage")},
+					{path.New(TAG_CHILD, "storage"), path.New(TAG_RESULT, "storage")},
+					{path.New(TAG_CHILD, "systemd", "units", 0, "deleteInherited"), path.New(TAG_RESULT, "systemd", "units", 0, "deleteInherited")},
+					{path.New(TAG_CHILD, "systemd", "units", 0, "name"), path.New(TAG_RESULT, "systemd", "units", 0, "name")},
+					{path.New(TAG_CHILD, "systemd", "units", 0), path.New(TAG_RESULT, "systemd", "units", 0)},
+					{path.New(TAG_CHILD, "systemd", "units"), path.New(TAG_RESULT, "systemd", "units")},
+					{path.New(TAG_PARENT, "systemd"), path.New(TAG_RESULT, "systemd")},
+					{path.New(TAG_CHILD, "systemd"), path.New(TAG_RESULT, "systemd")},
+				},
+				Deletions: []Deletion{
+					{path.New(TAG_PARENT, "passwd", "users", 0), path.New(TAG_CHILD, "passwd", "users", 0)},
+					{path.New(TAG_PARENT, "storage", "files", 0), path.New(TAG_CHILD, "storage", "files", 0)},
+					{path.New(TAG_PARENT, "storage", "files", 1), path.New(TAG_CHILD, "storage", "links", 0)},
+					{path.New(TAG_PARENT, "systemd", "units", 0), path.New(TAG_CHILD, "systemd", "units", 0)},
+				},
+			},
+		},
 	}
 
 	for i, test := range tests {
@@ -1349,6 +1460,104 @@ func TestMerge(t *testing.T) {
 	}
 }
 
+func TestMergeDeletionsForward(t *testing.T) {
+	// a deletion the first fragment can't satisfy deletes the entry from
+	// the config the fragments are merged into
+	first := types.Config{
+		Storage: types.Storage{
+			Files: []types.File{
+				{Node: types.Node{Path: "/a"}},
+			},
+		},
+	}
+	second := types.Config{
+		Storage: types.Storage{
+			Links: []types.Link{
+				{Node: types.Node{Path: "/b", DeleteInherited: util.BoolToPtr(true)}},
+			},
+		},
+	}
+	fragments, _ := MergeStructTranscribe(first, second)
+	assert.Equal(t, types.Config{
+		Storage: types.Storage{
+			Files: []types.File{
+				{Node: types.Node{Path: "/a"}},
+			},
+			Links: []types.Link{
+				{Node: types.Node{Path: "/b", DeleteInherited: util.BoolToPtr(true)}},
+			},
+		},
+	}, fragments)
+
+	user := types.Config{
+		Storage: types.Storage{
+			Files: []types.File{
+				{Node: types.Node{Path: "/b"}},
+				{Node: types.Node{Path: "/c"}},
+			},
+		},
+	}
+	result, transcript := MergeStructTranscribe(user, fragments)
+	assert.Equal(t, types.Config{
+		Storage: types.Storage{
+			Files: []types.File{
+				{Node: types.Node{Path: "/c"}},
+				{Node: types.Node{Path: "/a"}},
+			},
+			Links: []types.Link{
+				{Node: types.Node{Path: "/b", DeleteInherited: util.BoolToPtr(true)}},
+			},
+		},
+	}, result)
+	assert.Equal(t, []Deletion{
+		{path.New(TAG_PARENT, "storage", "files", 0), path.New(TAG_CHILD, "storage", "links", 0)},
+	}, transcript.Deletions)
+}
+
+func TestStripDeletions(t *testing.T) {
+	in := types.Config{
+		Passwd: types.Passwd{
+			Users: []types.PasswdUser{
+				{Name: "nobody", DeleteInherited: util.BoolToPtr(true)},
+				{Name: "core", Shell: util.StrToPtr("/bin/bash")},
+			},
+		},
+		Storage: types.Storage{
+			Files: []types.File{
+				{Node: types.Node{Path: "/a"}},
+			},
+			Links: []types.Link{
+				{Node: types.Node{Path: "/b", DeleteInherited: util.BoolToPtr(true)}},
+			},
+		},
+	}
+	outi, transcript := StripDeletionsTranscribe(in)
+	assert.Equal(t, types.Config{
+		Passwd: types.Passwd{
+			Users: []types.PasswdUser{
+				{Name: "core", Shell: util.StrToPtr("/bin/bash")},
+			},
+		},
+		Storage: types.Storage{
+			Files: []types.File{
+				{Node: types.Node{Path: "/a"}},
+			},
+			Links: []types.Link{},
+		},
+	}, outi.(types.Config))
+	assert.Equal(t, Transcript{Mappings: []Mapping{
+		{path.New(TAG_CHILD, "passwd", "users", 1, "name"), path.New(TAG_RESULT, "passwd", "users", 0, "name")},
+		{path.New(TAG_CHILD, "passwd", "users", 1, "shell"), path.New(TAG_RESULT, "passwd", "users", 0, "shell")},
+		{path.New(TAG_CHILD, "passwd", "users", 1), path.New(TAG_RESULT, "passwd", "users", 0)},
+		{path.New(TAG_CHILD, "passwd", "users"), path.New(TAG_RESULT, "passwd", "users")},
+		{path.New(TAG_CHILD, "passwd"), path.New(TAG_RESULT, "passwd")},
+		{path.New(TAG_CHILD, "storage", "files", 0, "path"), path.New(TAG_RESULT, "storage", "files", 0, "path")},
+		{path.New(TAG_CHILD, "storage", "files", 0), path.New(TAG_RESULT, "storage", "files", 0)},
+		{path.New(TAG_CHILD, "storage", "files"), path.New(TAG_RESULT, "storage", "files")},
+		{path.New(TAG_CHILD, "storage"), path.New(TAG_RESULT, "storage")},
+	}}, transcript)
+}
+
 // We are explicitly testing 3.2.0 because it mistakenly has struct
 // pointers. These should not exist but ended up in the Clevis & Luks
 // structs in spec 3.2.0.
@@ -1426,7 +1635,7 @@ func TestMergeStructPointers(t *testing.T) {
 					},
 				},
 			},
-			transcript: Transcript{[]Mapping{
+			transcript: Transcript{Mappings: []Mapping{
 				{path.New(TAG_PARENT, "storage", "luks", 0, "clevis", "custom", "config"), path.New(TAG_RESULT, "storage", "luks", 0, "clevis", "custom", "config")},
 				{path.New(TAG_PARENT, "storage", "luks", 0, "clevis", "custom", "pin"), path.New(TAG_RESULT, "storage", "luks", 0, "clevis", "custom", "pin")},
 				{path.New(TAG_PARENT, "storage", "luks", 0, "clevis", "custom"), path.New(TAG_RESULT, "storage", "luks", 0, "clevis", "custom")},
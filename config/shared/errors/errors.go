@@ -66,6 +66,7 @@ var (
 	ErrFileIllegalMode           = errors.New("illegal file mode")
 	ErrModeSpecialBits           = errors.New("setuid/setgid/sticky bits are not supported or functional in spec versions older than 3.6.0")
 	ErrBothIDAndNameSet          = errors.New("cannot set both id and name")
+	ErrDeleteInheritedWithFields = errors.New("entries which delete inherited entries cannot set other fields")
 	ErrLabelTooLong              = errors.New("partition labels may not exceed 36 characters")
 	ErrDoesntMatchGUIDRegex      = errors.New("doesn't match the form \"01234567-89AB-CDEF-EDCB-A98765432101\"")
 	ErrLabelContainsColon        = errors.New("partition label will be truncated to text before the colon")
@@ -84,6 +85,14 @@ var (
 	ErrDuplicateLabels           = errors.New("cannot use the same partition label twice")
 	ErrInvalidProxy              = errors.New("proxies must be http(s)")
 	ErrInsecureProxy             = errors.New("insecure plaintext HTTP proxy specified for HTTPS resources")
+	ErrClientCertKeyIncomplete   = errors.New("TLS client key specified without a client certificate")
+	ErrInsecureClientKey         = errors.New("TLS client key is fetched over an unencrypted connection")
+	ErrInvalidTLSPin             = errors.New("pin must be a base64-encoded SHA-256 hash of a subject public key info")
+	ErrInvalidTLSPinHost         = errors.New("pin host must be a hostname or IP address without a scheme or port")
+	ErrRetryValueNegative        = errors.New("retry settings must not be negative")
+	ErrRetryBackoffZero          = errors.New("initial backoff must be positive")
+	ErrRetryJitterTooLarge       = errors.New("retry jitter must be a percentage from 0 to 100")
+	ErrRetryBackoffExceedsMax    = errors.New("initial backoff must not exceed maximum backoff")
 	ErrPathConflictsSystemd      = errors.New("path conflicts with systemd unit or dropin")
 	ErrCexWithClevis             = errors.New("cannot use cex with clevis")
 	ErrCexWithKeyFile            = errors.New("cannot use key file with cex")
@@ -105,10 +114,17 @@ var (
 	ErrHashWrongSize                   = errors.New("incorrect size for hash sum")
 	ErrHashUnrecognized                = errors.New("unrecognized hash function")
 	ErrEngineConfiguration             = errors.New("engine incorrectly configured")
+	ErrConfigReferenceCycle            = errors.New("config references form a cycle")
+	ErrConfigReferenceDepth            = errors.New("config references nested too deeply")
 
 	// AWS S3 specific errors
 	ErrInvalidS3ARN             = errors.New("invalid S3 ARN format")
 	ErrInvalidS3ObjectVersionId = errors.New("invalid S3 object VersionId")
+	ErrInvalidS3Endpoint        = errors.New("S3 endpoint must be an http(s) URL")
+	ErrS3CredentialsIncomplete  = errors.New("S3 access key ID and secret access key must be specified together")
+
+	// OCI registry specific errors
+	ErrInvalidOCIReference = errors.New("invalid OCI reference: must be oci://<registry>/<repository>:<tag> or oci://<registry>/<repository>@<digest>")
 
 	// Obsolete errors, left here for ABI compatibility
 	ErrFilePermissionsUnset      = errors.New("permissions unset, defaulting to 0644")
@@ -30,6 +30,21 @@ type Keyed interface {
 	Key() string
 }
 
+// DeletesInherited is implemented by keyed list entries which can direct
+// the merge to delete the parent's entry with the same key.
+type DeletesInherited interface {
+	DeletesInherited() bool
+}
+
+// CallDeletesInherited is a helper to call the DeletesInherited() function
+// of list entries which implement it
+func CallDeletesInherited(v reflect.Value) bool {
+	if deleter, ok := v.Interface().(DeletesInherited); ok {
+		return deleter.DeletesInherited()
+	}
+	return false
+}
+
 // CallKey is a helper to call the Key() function since this needs to happen a lot
 func CallKey(v reflect.Value) string {
 	if v.Kind() == reflect.String {
@@ -32,6 +32,14 @@ func Merge(parent, child types.Config) types.Config {
 	return res.(types.Config)
 }
 
+// StripDeletions removes the entries which delete inherited entries and
+// which weren't merged into an entry to delete. Callers should use it on
+// the final merged config.
+func StripDeletions(cfg types.Config) types.Config {
+	res, _ := merge.StripDeletionsTranscribe(cfg)
+	return res.(types.Config)
+}
+
 // Parse parses the raw config into a types.Config struct and generates a report of any
 // errors, warnings, info, and deprecations it encountered
 func Parse(rawConfig []byte) (types.Config, report.Report, error) {
@@ -20,16 +20,110 @@ import (
 	"github.com/coreos/ignition/v2/config/v3_7_experimental/types"
 )
 
+func translateHTTPHeader(old old_types.HTTPHeader) (ret types.HTTPHeader) {
+	tr := translate.NewTranslator()
+	tr.Translate(&old.Name, &ret.Name)
+	tr.Translate(&old.Value, &ret.Value)
+	return
+}
+
+func translateResource(old old_types.Resource) (ret types.Resource) {
+	// use a new translator so we don't recurse infinitely
+	tr := translate.NewTranslator()
+	tr.AddCustomTranslator(translateHTTPHeader)
+	tr.Translate(&old.Compression, &ret.Compression)
+	tr.Translate(&old.HTTPHeaders, &ret.HTTPHeaders)
+	tr.Translate(&old.Source, &ret.Source)
+	tr.Translate(&old.Verification, &ret.Verification)
+	return
+}
+
+func translateTimeouts(old old_types.Timeouts) (ret types.Timeouts) {
+	// use a new translator so we don't recurse infinitely
+	tr := translate.NewTranslator()
+	tr.Translate(&old.HTTPResponseHeaders, &ret.HTTPResponseHeaders)
+	tr.Translate(&old.HTTPTotal, &ret.HTTPTotal)
+	return
+}
+
+func translateTLS(old old_types.TLS) (ret types.TLS) {
+	// use a new translator so we don't recurse infinitely
+	tr := translate.NewTranslator()
+	tr.AddCustomTranslator(translateResource)
+	tr.Translate(&old.CertificateAuthorities, &ret.CertificateAuthorities)
+	return
+}
+
+func translateSecurity(old old_types.Security) (ret types.Security) {
+	// use a new translator so we don't recurse infinitely
+	tr := translate.NewTranslator()
+	tr.AddCustomTranslator(translateTLS)
+	tr.Translate(&old.TLS, &ret.TLS)
+	return
+}
+
 func translateIgnition(old old_types.Ignition) (ret types.Ignition) {
 	// use a new translator so we don't recurse infinitely
-	translate.NewTranslator().Translate(&old, &ret)
+	tr := translate.NewTranslator()
+	tr.AddCustomTranslator(translateResource)
+	tr.AddCustomTranslator(translateSecurity)
+	tr.AddCustomTranslator(translateTimeouts)
+	tr.Translate(&old.Config, &ret.Config)
+	tr.Translate(&old.Proxy, &ret.Proxy)
+	tr.Translate(&old.Security, &ret.Security)
+	tr.Translate(&old.Timeouts, &ret.Timeouts)
 	ret.Version = types.MaxVersion.String()
 	return
 }
 
+func translateNode(old old_types.Node) (ret types.Node) {
+	// use a new translator so we don't recurse infinitely
+	tr := translate.NewTranslator()
+	tr.Translate(&old.Group, &ret.Group)
+	tr.Translate(&old.Overwrite, &ret.Overwrite)
+	tr.Translate(&old.Path, &ret.Path)
+	tr.Translate(&old.User, &ret.User)
+	return
+}
+
+func translateUnit(old old_types.Unit) (ret types.Unit) {
+	// use a new translator so we don't recurse infinitely
+	tr := translate.NewTranslator()
+	tr.Translate(&old.Contents, &ret.Contents)
+	tr.Translate(&old.Dropins, &ret.Dropins)
+	tr.Translate(&old.Enabled, &ret.Enabled)
+	tr.Translate(&old.Mask, &ret.Mask)
+	tr.Translate(&old.Name, &ret.Name)
+	return
+}
+
+func translatePasswdUser(old old_types.PasswdUser) (ret types.PasswdUser) {
+	// use a new translator so we don't recurse infinitely
+	tr := translate.NewTranslator()
+	tr.Translate(&old.Gecos, &ret.Gecos)
+	tr.Translate(&old.Groups, &ret.Groups)
+	tr.Translate(&old.HomeDir, &ret.HomeDir)
+	tr.Translate(&old.Name, &ret.Name)
+	tr.Translate(&old.NoCreateHome, &ret.NoCreateHome)
+	tr.Translate(&old.NoLogInit, &ret.NoLogInit)
+	tr.Translate(&old.NoUserGroup, &ret.NoUserGroup)
+	tr.Translate(&old.PasswordHash, &ret.PasswordHash)
+	tr.Translate(&old.PrimaryGroup, &ret.PrimaryGroup)
+	tr.Translate(&old.SSHAuthorizedKeys, &ret.SSHAuthorizedKeys)
+	tr.Translate(&old.Shell, &ret.Shell)
+	tr.Translate(&old.ShouldExist, &ret.ShouldExist)
+	tr.Translate(&old.System, &ret.System)
+	tr.Translate(&old.UID, &ret.UID)
+	return
+}
+
 func Translate(old old_types.Config) (ret types.Config) {
 	tr := translate.NewTranslator()
 	tr.AddCustomTranslator(translateIgnition)
+	tr.AddCustomTranslator(translateResource)
+	tr.AddCustomTranslator(translateNode)
+	tr.AddCustomTranslator(translatePasswdUser)
+	tr.AddCustomTranslator(translateUnit)
 	tr.Translate(&old, &ret)
 	return
 }
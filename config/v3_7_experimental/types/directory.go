@@ -22,5 +22,8 @@ import (
 func (d Directory) Validate(c path.ContextPath) (r report.Report) {
 	r.Merge(d.Node.Validate(c))
 	r.AddOnError(c.Append("mode"), validateMode(d.Mode))
+	if d.DeletesInherited() {
+		r.AddOnError(c.Append("deleteInherited"), validateDeleteInherited(d, Directory{Node: Node{Path: d.Path, DeleteInherited: d.DeleteInherited}}))
+	}
 	return
 }
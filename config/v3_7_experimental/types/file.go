@@ -26,6 +26,9 @@ func (f File) Validate(c path.ContextPath) (r report.Report) {
 	r.Merge(f.Node.Validate(c))
 	r.AddOnError(c.Append("mode"), validateMode(f.Mode))
 	r.AddOnError(c.Append("overwrite"), f.validateOverwrite())
+	if f.DeletesInherited() {
+		r.AddOnError(c.Append("deleteInherited"), validateDeleteInherited(f, File{Node: Node{Path: f.Path, DeleteInherited: f.DeleteInherited}}))
+	}
 	return
 }
 
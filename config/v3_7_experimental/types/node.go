@@ -16,6 +16,7 @@ package types
 
 import (
 	"path"
+	"reflect"
 
 	"github.com/coreos/ignition/v2/config/shared/errors"
 	"github.com/coreos/ignition/v2/config/util"
@@ -28,6 +29,10 @@ func (n Node) Key() string {
 	return n.Path
 }
 
+func (n Node) DeletesInherited() bool {
+	return util.IsTrue(n.DeleteInherited)
+}
+
 func (n Node) Validate(c vpath.ContextPath) (r report.Report) {
 	r.AddOnError(c.Append("path"), validatePath(n.Path))
 	return
@@ -41,6 +46,15 @@ func (n Node) Depth() int {
 	return count
 }
 
+// validateDeleteInherited checks that an entry which deletes the inherited
+// entry with the same key sets nothing but its key.
+func validateDeleteInherited(entry, keyOnly interface{}) error {
+	if !reflect.DeepEqual(entry, keyOnly) {
+		return errors.ErrDeleteInheritedWithFields
+	}
+	return nil
+}
+
 func validateIDorName(id *int, name *string) error {
 	if id != nil && util.NotEmpty(name) {
 		return errors.ErrBothIDAndNameSet
@@ -99,3 +99,63 @@ func TestNodeValidateGroup(t *testing.T) {
 		}
 	}
 }
+
+func TestDeleteInheritedValidate(t *testing.T) {
+	tests := []struct {
+		in interface {
+			Validate(path.ContextPath) report.Report
+		}
+		at  path.ContextPath
+		out error
+	}{
+		{
+			in: File{Node: Node{Path: "/a", DeleteInherited: util.BoolToPtr(true)}},
+		},
+		{
+			in:  File{Node: Node{Path: "/a", DeleteInherited: util.BoolToPtr(true)}, FileEmbedded1: FileEmbedded1{Mode: util.IntToPtr(0644)}},
+			at:  path.ContextPath{}.Append("deleteInherited"),
+			out: errors.ErrDeleteInheritedWithFields,
+		},
+		{
+			in:  Directory{Node: Node{Path: "/a", DeleteInherited: util.BoolToPtr(true), Overwrite: util.BoolToPtr(true)}},
+			at:  path.ContextPath{}.Append("deleteInherited"),
+			out: errors.ErrDeleteInheritedWithFields,
+		},
+		{
+			in: Storage{Links: []Link{{Node: Node{Path: "/a", DeleteInherited: util.BoolToPtr(true)}}}},
+		},
+		{
+			in:  Storage{Links: []Link{{Node: Node{Path: "/a", DeleteInherited: util.BoolToPtr(true)}, LinkEmbedded1: LinkEmbedded1{Target: util.StrToPtr("/b")}}}},
+			at:  path.ContextPath{}.Append("links", 0, "deleteInherited"),
+			out: errors.ErrDeleteInheritedWithFields,
+		},
+		{
+			in: Unit{Name: "a.service", DeleteInherited: util.BoolToPtr(true)},
+		},
+		{
+			in:  Unit{Name: "a.service", DeleteInherited: util.BoolToPtr(true), Enabled: util.BoolToPtr(true)},
+			at:  path.ContextPath{}.Append("deleteInherited"),
+			out: errors.ErrDeleteInheritedWithFields,
+		},
+		{
+			in: PasswdUser{Name: "core", DeleteInherited: util.BoolToPtr(true)},
+		},
+		{
+			in:  PasswdUser{Name: "core", DeleteInherited: util.BoolToPtr(true), Shell: util.StrToPtr("/bin/sh")},
+			at:  path.ContextPath{}.Append("deleteInherited"),
+			out: errors.ErrDeleteInheritedWithFields,
+		},
+		{
+			in: PasswdUser{Name: "core", DeleteInherited: util.BoolToPtr(false), Shell: util.StrToPtr("/bin/sh")},
+		},
+	}
+
+	for i, test := range tests {
+		r := test.in.Validate(path.ContextPath{})
+		expected := report.Report{}
+		expected.AddOnError(test.at, test.out)
+		if !reflect.DeepEqual(expected, r) {
+			t.Errorf("#%d: bad report: want %v got %v", i, expected, r)
+		}
+	}
+}
@@ -14,10 +14,28 @@
 
 package types
 
+import (
+	"github.com/coreos/ignition/v2/config/util"
+
+	"github.com/coreos/vcontext/path"
+	"github.com/coreos/vcontext/report"
+)
+
 func (p PasswdUser) Key() string {
 	return p.Name
 }
 
+func (p PasswdUser) DeletesInherited() bool {
+	return util.IsTrue(p.DeleteInherited)
+}
+
+func (p PasswdUser) Validate(c path.ContextPath) (r report.Report) {
+	if p.DeletesInherited() {
+		r.AddOnError(c.Append("deleteInherited"), validateDeleteInherited(p, PasswdUser{Name: p.Name, DeleteInherited: p.DeleteInherited}))
+	}
+	return
+}
+
 func (g PasswdGroup) Key() string {
 	return g.Name
 }
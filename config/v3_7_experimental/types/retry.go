@@ -0,0 +1,44 @@
+// Copyright 2026 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"github.com/coreos/ignition/v2/config/shared/errors"
+
+	"github.com/coreos/vcontext/path"
+	"github.com/coreos/vcontext/report"
+)
+
+func (r Retry) Validate(c path.ContextPath) (rep report.Report) {
+	checkNonNegative := func(field string, value *int) {
+		if value != nil && *value < 0 {
+			rep.AddOnError(c.Append(field), errors.ErrRetryValueNegative)
+		}
+	}
+	checkNonNegative("maxAttempts", r.MaxAttempts)
+	if r.InitialBackoff != nil && *r.InitialBackoff <= 0 {
+		// a zero backoff would retry in a tight loop
+		rep.AddOnError(c.Append("initialBackoff"), errors.ErrRetryBackoffZero)
+	}
+	checkNonNegative("maxBackoff", r.MaxBackoff)
+	checkNonNegative("jitter", r.Jitter)
+	if r.Jitter != nil && *r.Jitter > 100 {
+		rep.AddOnError(c.Append("jitter"), errors.ErrRetryJitterTooLarge)
+	}
+	if r.InitialBackoff != nil && r.MaxBackoff != nil && *r.InitialBackoff > *r.MaxBackoff {
+		rep.AddOnError(c.Append("initialBackoff"), errors.ErrRetryBackoffExceedsMax)
+	}
+	return
+}
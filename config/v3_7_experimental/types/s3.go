@@ -0,0 +1,47 @@
+// Copyright 2026 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"net/url"
+
+	"github.com/coreos/ignition/v2/config/shared/errors"
+	"github.com/coreos/ignition/v2/config/util"
+
+	"github.com/coreos/vcontext/path"
+	"github.com/coreos/vcontext/report"
+)
+
+func (s S3) Validate(c path.ContextPath) (r report.Report) {
+	r.AddOnError(c.Append("endpoint"), validateS3Endpoint(s.Endpoint))
+	if util.NilOrEmpty(s.AccessKeyID) != util.NilOrEmpty(s.SecretAccessKey) {
+		r.AddOnError(c.Append("secretAccessKey"), errors.ErrS3CredentialsIncomplete)
+	}
+	return
+}
+
+func validateS3Endpoint(s *string) error {
+	if util.NilOrEmpty(s) {
+		return nil
+	}
+	u, err := url.Parse(*s)
+	if err != nil {
+		return errors.ErrInvalidUrl
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.ErrInvalidS3Endpoint
+	}
+	return nil
+}
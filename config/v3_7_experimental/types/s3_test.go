@@ -0,0 +1,91 @@
+// Copyright 2026 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/coreos/ignition/v2/config/shared/errors"
+	"github.com/coreos/ignition/v2/config/util"
+
+	"github.com/coreos/vcontext/path"
+	"github.com/coreos/vcontext/report"
+)
+
+func TestS3Validate(t *testing.T) {
+	tests := []struct {
+		in  S3
+		at  path.ContextPath
+		out error
+	}{
+		{
+			in:  S3{},
+			out: nil,
+		},
+		{
+			in: S3{
+				Endpoint:        util.StrToPtr("https://minio.example.com:9000"),
+				UsePathStyle:    util.BoolToPtr(true),
+				AccessKeyID:     util.StrToPtr("AKID"),
+				SecretAccessKey: util.StrToPtr("secret"),
+			},
+			out: nil,
+		},
+		{
+			in: S3{
+				Endpoint: util.StrToPtr("http://10.0.0.1:7480"),
+			},
+			out: nil,
+		},
+		{
+			in: S3{
+				Endpoint: util.StrToPtr("s3://bucket"),
+			},
+			at:  path.New("", "endpoint"),
+			out: errors.ErrInvalidS3Endpoint,
+		},
+		{
+			in: S3{
+				Endpoint: util.StrToPtr("https://"),
+			},
+			at:  path.New("", "endpoint"),
+			out: errors.ErrInvalidS3Endpoint,
+		},
+		{
+			in: S3{
+				AccessKeyID: util.StrToPtr("AKID"),
+			},
+			at:  path.New("", "secretAccessKey"),
+			out: errors.ErrS3CredentialsIncomplete,
+		},
+		{
+			in: S3{
+				SecretAccessKey: util.StrToPtr("secret"),
+			},
+			at:  path.New("", "secretAccessKey"),
+			out: errors.ErrS3CredentialsIncomplete,
+		},
+	}
+
+	for i, test := range tests {
+		r := test.in.Validate(path.New(""))
+		expected := report.Report{}
+		expected.AddOnError(test.at, test.out)
+		if !reflect.DeepEqual(expected, r) {
+			t.Errorf("#%d: bad report: want %v, got %v", i, expected, r)
+		}
+	}
+}
@@ -76,8 +76,9 @@ type FilesystemOption string
 type Group string
 
 type HTTPHeader struct {
-	Name  string  `json:"name"`
-	Value *string `json:"value,omitempty"`
+	Name      string  `json:"name"`
+	Sensitive *bool   `json:"sensitive,omitempty"`
+	Value     *string `json:"value,omitempty"`
 }
 
 type HTTPHeaders []HTTPHeader
@@ -85,6 +86,7 @@ type HTTPHeaders []HTTPHeader
 type Ignition struct {
 	Config   IgnitionConfig `json:"config,omitempty"`
 	Proxy    Proxy          `json:"proxy,omitempty"`
+	S3       S3             `json:"s3,omitempty"`
 	Security Security       `json:"security,omitempty"`
 	Timeouts Timeouts       `json:"timeouts,omitempty"`
 	Version  string         `json:"version"`
@@ -133,10 +135,11 @@ type MountOption string
 type NoProxyItem string
 
 type Node struct {
-	Group     NodeGroup `json:"group,omitempty"`
-	Overwrite *bool     `json:"overwrite,omitempty"`
-	Path      string    `json:"path"`
-	User      NodeUser  `json:"user,omitempty"`
+	DeleteInherited *bool     `json:"deleteInherited,omitempty"`
+	Group           NodeGroup `json:"group,omitempty"`
+	Overwrite       *bool     `json:"overwrite,omitempty"`
+	Path            string    `json:"path"`
+	User            NodeUser  `json:"user,omitempty"`
 }
 
 type NodeGroup struct {
@@ -177,6 +180,7 @@ type PasswdGroup struct {
 }
 
 type PasswdUser struct {
+	DeleteInherited   *bool              `json:"deleteInherited,omitempty"`
 	Gecos             *string            `json:"gecos,omitempty"`
 	Groups            []Group            `json:"groups,omitempty"`
 	HomeDir           *string            `json:"homeDir,omitempty"`
@@ -185,6 +189,7 @@ type PasswdUser struct {
 	NoLogInit         *bool              `json:"noLogInit,omitempty"`
 	NoUserGroup       *bool              `json:"noUserGroup,omitempty"`
 	PasswordHash      *string            `json:"passwordHash,omitempty"`
+	PlatformSSHKeys   *bool              `json:"platformSSHKeys,omitempty"`
 	PrimaryGroup      *string            `json:"primaryGroup,omitempty"`
 	SSHAuthorizedKeys []SSHAuthorizedKey `json:"sshAuthorizedKeys,omitempty"`
 	Shell             *string            `json:"shell,omitempty"`
@@ -212,10 +217,27 @@ type RaidOption string
 type Resource struct {
 	Compression  *string      `json:"compression,omitempty"`
 	HTTPHeaders  HTTPHeaders  `json:"httpHeaders,omitempty"`
+	Retry        Retry        `json:"retry,omitempty"`
+	Sensitive    *bool        `json:"sensitive,omitempty"`
 	Source       *string      `json:"source,omitempty"`
 	Verification Verification `json:"verification,omitempty"`
 }
 
+type Retry struct {
+	InitialBackoff *int `json:"initialBackoff,omitempty"`
+	Jitter         *int `json:"jitter,omitempty"`
+	MaxAttempts    *int `json:"maxAttempts,omitempty"`
+	MaxBackoff     *int `json:"maxBackoff,omitempty"`
+}
+
+type S3 struct {
+	AccessKeyID     *string `json:"accessKeyId,omitempty"`
+	Endpoint        *string `json:"endpoint,omitempty"`
+	Region          *string `json:"region,omitempty"`
+	SecretAccessKey *string `json:"secretAccessKey,omitempty"`
+	UsePathStyle    *bool   `json:"usePathStyle,omitempty"`
+}
+
 type SSHAuthorizedKey string
 
 type Security struct {
@@ -238,6 +260,14 @@ type Systemd struct {
 
 type TLS struct {
 	CertificateAuthorities []Resource `json:"certificateAuthorities,omitempty"`
+	ClientCertificate      Resource   `json:"clientCertificate,omitempty"`
+	ClientKey              Resource   `json:"clientKey,omitempty"`
+	Pins                   []TLSPin   `json:"pins,omitempty"`
+}
+
+type TLSPin struct {
+	Host   *string `json:"host,omitempty"`
+	SHA256 string  `json:"sha256"`
 }
 
 type Tang struct {
@@ -247,16 +277,18 @@ type Tang struct {
 }
 
 type Timeouts struct {
-	HTTPResponseHeaders *int `json:"httpResponseHeaders,omitempty"`
-	HTTPTotal           *int `json:"httpTotal,omitempty"`
+	HTTPResponseHeaders *int  `json:"httpResponseHeaders,omitempty"`
+	HTTPTotal           *int  `json:"httpTotal,omitempty"`
+	Retry               Retry `json:"retry,omitempty"`
 }
 
 type Unit struct {
-	Contents *string  `json:"contents,omitempty"`
-	Dropins  []Dropin `json:"dropins,omitempty"`
-	Enabled  *bool    `json:"enabled,omitempty"`
-	Mask     *bool    `json:"mask,omitempty"`
-	Name     string   `json:"name"`
+	Contents        *string  `json:"contents,omitempty"`
+	DeleteInherited *bool    `json:"deleteInherited,omitempty"`
+	Dropins         []Dropin `json:"dropins,omitempty"`
+	Enabled         *bool    `json:"enabled,omitempty"`
+	Mask            *bool    `json:"mask,omitempty"`
+	Name            string   `json:"name"`
 }
 
 type Verification struct {
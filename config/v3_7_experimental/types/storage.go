@@ -68,6 +68,10 @@ func (s Storage) validateLinks(c vpath.ContextPath, r *report.Report) {
 				r.AddOnError(c.Append("links", i), errors.ErrLinkUsedSymlink)
 			}
 		}
+		if l1.DeletesInherited() {
+			r.AddOnError(c.Append("links", i, "deleteInherited"), validateDeleteInherited(l1, Link{Node: Node{Path: l1.Path, DeleteInherited: l1.DeleteInherited}}))
+			continue
+		}
 		if util.NilOrEmpty(l1.Target) {
 			r.AddOnError(c.Append("links", i, "target"), errors.ErrLinkTargetRequired)
 			continue
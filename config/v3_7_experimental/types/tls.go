@@ -15,6 +15,13 @@
 package types
 
 import (
+	"encoding/base64"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/coreos/ignition/v2/config/shared/errors"
+
 	"github.com/coreos/vcontext/path"
 	"github.com/coreos/vcontext/report"
 )
@@ -23,5 +30,33 @@ func (tls TLS) Validate(c path.ContextPath) (r report.Report) {
 	for i, ca := range tls.CertificateAuthorities {
 		r.AddOnError(c.Append("certificateAuthorities", i), ca.validateRequiredSource())
 	}
+	if tls.ClientCertificate.Source == nil && tls.ClientKey.Source != nil {
+		r.AddOnError(c.Append("clientCertificate"), errors.ErrClientCertKeyIncomplete)
+	}
+	if tls.ClientKey.Source != nil {
+		if u, err := url.Parse(*tls.ClientKey.Source); err == nil && (u.Scheme == "http" || u.Scheme == "tftp") {
+			r.AddOnWarn(c.Append("clientKey", "source"), errors.ErrInsecureClientKey)
+		}
+	}
+	return
+}
+
+func (p TLSPin) Key() string {
+	if p.Host == nil {
+		return p.SHA256
+	}
+	return *p.Host + " " + p.SHA256
+}
+
+func (p TLSPin) Validate(c path.ContextPath) (r report.Report) {
+	if sum, err := base64.StdEncoding.DecodeString(p.SHA256); err != nil || len(sum) != 32 {
+		r.AddOnError(c.Append("sha256"), errors.ErrInvalidTLSPin)
+	}
+	if p.Host != nil {
+		host := *p.Host
+		if host == "" || strings.ContainsAny(host, "/@") || (strings.Contains(host, ":") && net.ParseIP(host) == nil) {
+			r.AddOnError(c.Append("host"), errors.ErrInvalidTLSPinHost)
+		}
+	}
 	return
 }
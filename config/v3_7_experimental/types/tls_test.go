@@ -17,6 +17,8 @@ package types
 import (
 	"testing"
 
+	"github.com/coreos/ignition/v2/config/util"
+
 	"github.com/coreos/vcontext/validate"
 )
 
@@ -31,10 +33,70 @@ func TestTLSValidate(t *testing.T) {
 			},
 			"error at $.certificateAuthorities.0: source is required\n",
 		},
+		{
+			TLS{
+				ClientCertificate: Resource{Source: util.StrToPtr("https://example.com/client.crt")},
+				ClientKey:         Resource{Source: util.StrToPtr("data:,key")},
+			},
+			"",
+		},
+		{
+			TLS{
+				ClientCertificate: Resource{Source: util.StrToPtr("https://example.com/client.crt")},
+			},
+			"",
+		},
+		{
+			TLS{
+				ClientKey: Resource{Source: util.StrToPtr("data:,key")},
+			},
+			"error at $.clientCertificate: TLS client key specified without a client certificate\n",
+		},
+		{
+			TLS{
+				ClientCertificate: Resource{Source: util.StrToPtr("https://example.com/client.crt")},
+				ClientKey:         Resource{Source: util.StrToPtr("http://example.com/client.key")},
+			},
+			"warning at $.clientKey.source: TLS client key is fetched over an unencrypted connection\n",
+		},
+		{
+			TLS{
+				Pins: []TLSPin{
+					{SHA256: "47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="},
+					{Host: util.StrToPtr("config.example.com"), SHA256: "47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="},
+					{Host: util.StrToPtr("2001:db8::1"), SHA256: "47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="},
+				},
+			},
+			"",
+		},
+		{
+			TLS{
+				Pins: []TLSPin{{SHA256: "47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU"}},
+			},
+			"error at $.pins.0.sha256: pin must be a base64-encoded SHA-256 hash of a subject public key info\n",
+		},
+		{
+			TLS{
+				Pins: []TLSPin{{SHA256: "dGVzdA=="}},
+			},
+			"error at $.pins.0.sha256: pin must be a base64-encoded SHA-256 hash of a subject public key info\n",
+		},
+		{
+			TLS{
+				Pins: []TLSPin{{Host: util.StrToPtr("config.example.com:443"), SHA256: "47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="}},
+			},
+			"error at $.pins.0.host: pin host must be a hostname or IP address without a scheme or port\n",
+		},
+		{
+			TLS{
+				Pins: []TLSPin{{Host: util.StrToPtr("https://config.example.com"), SHA256: "47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="}},
+			},
+			"error at $.pins.0.host: pin host must be a hostname or IP address without a scheme or port\n",
+		},
 	}
 
 	for i, test := range tests {
-		r := validate.Validate(test.in, "test")
+		r := validate.Validate(test.in, "json")
 		if test.out != r.String() {
 			t.Errorf("#%d: bad error: want %q, got %q", i, test.out, r.String())
 		}
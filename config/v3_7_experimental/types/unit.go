@@ -34,8 +34,15 @@ func (d Dropin) Key() string {
 	return d.Name
 }
 
+func (u Unit) DeletesInherited() bool {
+	return util.IsTrue(u.DeleteInherited)
+}
+
 func (u Unit) Validate(c cpath.ContextPath) (r report.Report) {
 	r.AddOnError(c.Append("name"), validateName(u.Name))
+	if u.DeletesInherited() {
+		r.AddOnError(c.Append("deleteInherited"), validateDeleteInherited(u, Unit{Name: u.Name, DeleteInherited: u.DeleteInherited}))
+	}
 	c = c.Append("contents")
 	opts, err := parse.ParseUnitContents(u.Contents)
 	r.AddOnError(c, err)
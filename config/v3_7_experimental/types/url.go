@@ -65,6 +65,8 @@ func validateURL(s string) error {
 			}
 		}
 		return nil
+	case "oci":
+		return validateOCIURL(u)
 	case "data":
 		if _, err := dataurl.DecodeString(s); err != nil {
 			return err
@@ -75,6 +77,48 @@ func validateURL(s string) error {
 	}
 }
 
+// validateOCIURL checks that u has the form
+// oci://<registry>/<repository>(:<tag>|@<algorithm>:<hex>)[?path=<path>].
+func validateOCIURL(u *url.URL) error {
+	if u.Host == "" {
+		return errors.ErrInvalidOCIReference
+	}
+	name := strings.TrimPrefix(u.Path, "/")
+	var repository string
+	if i := strings.LastIndex(name, "@"); i >= 0 {
+		repository = name[:i]
+		algorithm, sum, ok := strings.Cut(name[i+1:], ":")
+		if !ok {
+			return errors.ErrInvalidOCIReference
+		}
+		var size int
+		switch algorithm {
+		case "sha256":
+			size = 64
+		case "sha512":
+			size = 128
+		default:
+			return errors.ErrHashUnrecognized
+		}
+		if len(sum) != size {
+			return errors.ErrHashWrongSize
+		}
+	} else if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") && i < len(name)-1 {
+		repository = name[:i]
+	} else {
+		return errors.ErrInvalidOCIReference
+	}
+	if repository == "" || strings.HasSuffix(repository, "/") {
+		return errors.ErrInvalidOCIReference
+	}
+	if v, ok := u.Query()["path"]; ok {
+		if len(v) != 1 || v[0] == "" {
+			return errors.ErrInvalidOCIReference
+		}
+	}
+	return nil
+}
+
 func validateURLNilOK(s *string) error {
 	if util.NilOrEmpty(s) {
 		return nil
@@ -126,6 +126,42 @@ func TestURLValidate(t *testing.T) {
 			util.StrToPtr("gs://bucket/object"),
 			nil,
 		},
+		{
+			util.StrToPtr("oci://registry.example.com/repo/name:v1"),
+			nil,
+		},
+		{
+			util.StrToPtr("oci://registry.example.com:5000/name@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"),
+			nil,
+		},
+		{
+			util.StrToPtr("oci://registry.example.com/repo:v1?path=/etc/motd"),
+			nil,
+		},
+		{
+			util.StrToPtr("oci://registry.example.com/repo"),
+			errors.ErrInvalidOCIReference,
+		},
+		{
+			util.StrToPtr("oci://registry.example.com/repo:"),
+			errors.ErrInvalidOCIReference,
+		},
+		{
+			util.StrToPtr("oci:///repo:v1"),
+			errors.ErrInvalidOCIReference,
+		},
+		{
+			util.StrToPtr("oci://registry.example.com/repo@md5:0123"),
+			errors.ErrHashUnrecognized,
+		},
+		{
+			util.StrToPtr("oci://registry.example.com/repo@sha256:0123"),
+			errors.ErrHashWrongSize,
+		},
+		{
+			util.StrToPtr("oci://registry.example.com/repo:v1?path="),
+			errors.ErrInvalidOCIReference,
+		},
 	}
 
 	for i, test := range tests {
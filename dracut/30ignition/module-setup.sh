@@ -117,6 +117,11 @@ installkernel() {
     # required by hyperv platform to read kvp from the kernel
     instmods hv_utils
 
+    # required by incus platform to mount the config drive
+    instmods virtiofs
+    instmods 9p
+    instmods 9pnet_virtio
+
     # required by applehv platform to read ignition file through vsock
     instmods -c vsock
     instmods -c vmw_vsock_virtio_transport_common
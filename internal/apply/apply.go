@@ -34,6 +34,7 @@ import (
 	"github.com/coreos/ignition/v2/internal/state"
 	"github.com/coreos/ignition/v2/internal/util"
 
+	latest "github.com/coreos/ignition/v2/config/v3_7_experimental"
 	"github.com/coreos/ignition/v2/config/v3_7_experimental/types"
 )
 
@@ -88,6 +89,9 @@ func Run(cfg types.Config, flags Flags, logger *log.Logger) error {
 	if err != nil {
 		return err
 	}
+	// drop entries which delete inherited entries, as Ignition does after
+	// merging the base config
+	finalCfg = latest.StripDeletions(finalCfg)
 
 	// verify upfront if we'll need networking but we're not allowed
 	if flags.Offline {
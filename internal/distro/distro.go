@@ -30,6 +30,10 @@ var (
 	bootIDPath        = "/proc/sys/kernel/random/boot_id"
 	// initramfs directory containing distro-provided base config
 	systemConfigDir = "/usr/lib/ignition"
+	// initramfs directory where the fetch stage caches remote resources
+	fetchCacheDir = "/run/ignition/fetch-cache"
+	// directory of the credentials imported by the service manager
+	systemCredentialsDir = "/run/credentials/@system"
 
 	// Helper programs
 	groupaddCmd  = "groupadd"
@@ -89,6 +93,17 @@ func DiskByLabelDir() string { return diskByLabelDir }
 func KernelCmdlinePath() string { return kernelCmdlinePath }
 func BootIDPath() string        { return bootIDPath }
 func SystemConfigDir() string   { return fromEnv("SYSTEM_CONFIG_DIR", systemConfigDir) }
+func FetchCacheDir() string     { return fromEnv("FETCH_CACHE_DIR", fetchCacheDir) }
+
+// CredentialDirs returns the directories to search for systemd
+// credentials, in order of precedence.
+func CredentialDirs() []string {
+	var dirs []string
+	if dir := os.Getenv("CREDENTIALS_DIRECTORY"); dir != "" {
+		dirs = append(dirs, dir)
+	}
+	return append(dirs, systemCredentialsDir)
+}
 
 func GroupaddCmd() string  { return groupaddCmd }
 func GroupmodCmd() string  { return groupmodCmd }
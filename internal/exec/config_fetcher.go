@@ -17,12 +17,18 @@ package exec
 import (
 	"crypto/sha512"
 	"encoding/hex"
+	"fmt"
 	"net/http"
 	"net/url"
+	"path"
+	"strings"
 
 	"github.com/coreos/ignition/v2/config"
 	"github.com/coreos/ignition/v2/config/shared/errors"
 	"github.com/coreos/ignition/v2/internal/log"
+	"github.com/coreos/ignition/v2/internal/provenance"
+	providersUtil "github.com/coreos/ignition/v2/internal/providers/util"
+	"github.com/coreos/ignition/v2/internal/redact"
 	"github.com/coreos/ignition/v2/internal/resource"
 	"github.com/coreos/ignition/v2/internal/state"
 	"github.com/coreos/ignition/v2/internal/util"
@@ -31,10 +37,33 @@ import (
 	"github.com/coreos/ignition/v2/config/v3_7_experimental/types"
 )
 
+// maxReferenceDepth is the maximum depth of configs referenced via
+// "ignition.config.replace" and "ignition.config.merge", counting the
+// rendered config as depth 0.
+const maxReferenceDepth = 10
+
 type ConfigFetcher struct {
 	Logger  *log.Logger
 	Fetcher *resource.Fetcher
 	State   *state.State
+	// Index in State.FetchedConfigs of the config to render, if recorded.
+	// The configs it references are recorded as its children.
+	Parent *int
+	// Whether RenderConfigProvenance tracks the origins of the fields of
+	// the referenced configs.
+	TrackProvenance bool
+}
+
+// reference identifies a config in a chain of config references.
+type reference struct {
+	// canonical URL, empty for the rendered config
+	url string
+	// SHA512 of the config as fetched, empty for the rendered config
+	hash string
+	// description for error messages
+	name string
+	// index in State.FetchedConfigs, if recorded
+	index *int
 }
 
 // RenderConfig evaluates "ignition.config.replace" and "ignition.config.merge"
@@ -43,67 +72,155 @@ type ConfigFetcher struct {
 // "ignition.config.merge" is set, each of the referenced configs will be
 // evaluated and merged into the provided config. If neither option is set, the
 // provided config will be returned unmodified. An updated fetcher will be
-// returned with any new timeouts set.
+// returned with any new timeouts set. Referencing a config which is already
+// being evaluated, with the same URL and contents, fails with
+// errors.ErrConfigReferenceCycle, and references nested more than
+// maxReferenceDepth deep fail with errors.ErrConfigReferenceDepth.
 func (f *ConfigFetcher) RenderConfig(cfg types.Config) (types.Config, error) {
-	if cfgRef := cfg.Ignition.Config.Replace; cfgRef.Source != nil {
-		newCfg, err := f.fetchReferencedConfig(cfgRef)
+	rendered, err := f.RenderConfigProvenance(provenance.Untracked(cfg))
+	if err != nil {
+		return types.Config{}, err
+	}
+	return rendered.Config, nil
+}
+
+// RenderConfigProvenance is RenderConfig, tracking the origins of the fields
+// of the rendered config if cfg tracks them and TrackProvenance is set. The
+// fields of referenced configs originate from their URLs.
+func (f *ConfigFetcher) RenderConfigProvenance(cfg provenance.Config) (provenance.Config, error) {
+	root := reference{
+		name:  "config",
+		index: f.Parent,
+	}
+	if f.Parent != nil {
+		root.name = fmt.Sprintf("%s config from %q", f.State.FetchedConfigs[*f.Parent].Kind, f.State.FetchedConfigs[*f.Parent].Source)
+	}
+	return f.renderConfig(cfg, []reference{root})
+}
+
+// renderConfig implements RenderConfigProvenance for a config referenced
+// through the given chain of configs, ending with the config itself.
+func (f *ConfigFetcher) renderConfig(cfg provenance.Config, chain []reference) (provenance.Config, error) {
+	// keep the config's secrets, such as the headers used to fetch the
+	// referenced configs, out of the log
+	f.Logger.Redact(redact.Secrets(cfg.Config)...)
+
+	if cfgRef := cfg.Config.Ignition.Config.Replace; cfgRef.Source != nil {
+		newCfg, ref, err := f.fetchReferencedConfig(cfgRef, chain)
 		if err != nil {
-			return types.Config{}, err
+			return provenance.Config{}, err
 		}
 
 		// Replace the HTTP client in the fetcher to be configured with the
 		// timeouts of the new config
-		err = f.Fetcher.UpdateHttpTimeoutsAndCAs(newCfg.Ignition.Timeouts, newCfg.Ignition.Security.TLS.CertificateAuthorities, newCfg.Ignition.Proxy)
+		f.Fetcher.S3Config = newCfg.Ignition.S3
+		err = f.Fetcher.UpdateHttpTimeoutsAndCAs(newCfg.Ignition.Timeouts, newCfg.Ignition.Security.TLS, newCfg.Ignition.Proxy)
 		if err != nil {
-			return types.Config{}, err
+			return provenance.Config{}, err
 		}
 
-		return f.RenderConfig(newCfg)
+		return f.renderConfig(f.newConfig(ref.name, newCfg), appendReference(chain, ref))
 	}
 
 	mergedCfg := cfg
-	for _, cfgRef := range cfg.Ignition.Config.Merge {
-		newCfg, err := f.fetchReferencedConfig(cfgRef)
+	for _, cfgRef := range cfg.Config.Ignition.Config.Merge {
+		newCfg, ref, err := f.fetchReferencedConfig(cfgRef, chain)
 		if err != nil {
-			return types.Config{}, err
+			return provenance.Config{}, err
 		}
 
 		// Merge the old config with the new config before the new config has
 		// been rendered, so we can use the new config's timeouts and CAs when
 		// fetching more configs.
-		cfgForFetcherSettings := latest.Merge(mergedCfg, newCfg)
-		err = f.Fetcher.UpdateHttpTimeoutsAndCAs(cfgForFetcherSettings.Ignition.Timeouts, cfgForFetcherSettings.Ignition.Security.TLS.CertificateAuthorities, cfgForFetcherSettings.Ignition.Proxy)
+		cfgForFetcherSettings := latest.Merge(mergedCfg.Config, newCfg)
+		f.Fetcher.S3Config = cfgForFetcherSettings.Ignition.S3
+		err = f.Fetcher.UpdateHttpTimeoutsAndCAs(cfgForFetcherSettings.Ignition.Timeouts, cfgForFetcherSettings.Ignition.Security.TLS, cfgForFetcherSettings.Ignition.Proxy)
 		if err != nil {
-			return types.Config{}, err
+			return provenance.Config{}, err
 		}
 
-		newCfg, err = f.RenderConfig(newCfg)
+		renderedCfg, err := f.renderConfig(f.newConfig(ref.name, newCfg), appendReference(chain, ref))
 		if err != nil {
-			return types.Config{}, err
+			return provenance.Config{}, err
 		}
 
-		mergedCfg = latest.Merge(mergedCfg, newCfg)
+		mergedCfg = provenance.Merge(mergedCfg, renderedCfg)
 	}
 	return mergedCfg, nil
 }
 
-// fetchReferencedConfig fetches and parses the requested config.
+// newConfig returns a referenced config, tracking the origins of its fields
+// if TrackProvenance is set.
+func (f *ConfigFetcher) newConfig(name string, cfg types.Config) provenance.Config {
+	if f.TrackProvenance {
+		return provenance.New(name, cfg)
+	}
+	return provenance.Untracked(cfg)
+}
+
+// appendReference returns a copy of chain with ref appended, so chains of
+// sibling references don't share a backing array.
+func appendReference(chain []reference, ref reference) []reference {
+	return append(chain[:len(chain):len(chain)], ref)
+}
+
+// describeChain describes a chain of config references for error messages.
+func describeChain(chain []reference) string {
+	var names []string
+	for _, ref := range chain {
+		names = append(names, ref.name)
+	}
+	return strings.Join(names, " -> ")
+}
+
+// canonicalURL normalizes a config URL for comparison, so different
+// spellings of the same URL are recognized.
+func canonicalURL(u url.URL) string {
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	if (u.Scheme == "http" && u.Port() == "80") || (u.Scheme == "https" && u.Port() == "443") {
+		u.Host = u.Hostname()
+	}
+	if u.Path != "" && u.Scheme != "data" {
+		u.Path = path.Clean(u.Path)
+		u.RawPath = ""
+	}
+	u.Fragment = ""
+	u.RawFragment = ""
+	return u.String()
+}
+
+// fetchReferencedConfig fetches and parses the requested config, referenced
+// by the last config of chain, and returns it along with its reference.
 // cfgRef.Source must not be nil
-func (f *ConfigFetcher) fetchReferencedConfig(cfgRef types.Resource) (types.Config, error) {
+func (f *ConfigFetcher) fetchReferencedConfig(cfgRef types.Resource, chain []reference) (types.Config, reference, error) {
 	// this is also already checked at validation time
 	if cfgRef.Source == nil {
 		f.Logger.Crit("invalid referenced config: %v", errors.ErrSourceRequired)
-		return types.Config{}, errors.ErrSourceRequired
+		return types.Config{}, reference{}, errors.ErrSourceRequired
 	}
 	u, err := url.Parse(*cfgRef.Source)
 	if err != nil {
-		return types.Config{}, err
+		return types.Config{}, reference{}, err
+	}
+	ref := reference{
+		url:  canonicalURL(*u),
+		name: u.Redacted(),
+	}
+	if u.Scheme == "data" {
+		// data url's might contain secrets
+		ref.name = "data url"
+	}
+	if len(chain) > maxReferenceDepth {
+		err := fmt.Errorf("%w: %s -> %s", errors.ErrConfigReferenceDepth, describeChain(chain), ref.name)
+		f.Logger.Crit("couldn't fetch referenced config: %v", err)
+		return types.Config{}, reference{}, err
 	}
 	var headers http.Header
 	if len(cfgRef.HTTPHeaders) > 0 {
 		headers, err = cfgRef.HTTPHeaders.Parse()
 		if err != nil {
-			return types.Config{}, err
+			return types.Config{}, reference{}, err
 		}
 	}
 	compression := ""
@@ -113,34 +230,54 @@ func (f *ConfigFetcher) fetchReferencedConfig(cfgRef types.Resource) (types.Conf
 	rawCfg, err := f.Fetcher.FetchToBuffer(*u, resource.FetchOptions{
 		Headers:     headers,
 		Compression: compression,
+		Retry:       cfgRef.Retry,
 	})
 	if err != nil {
-		return types.Config{}, err
+		return types.Config{}, reference{}, err
 	}
 
 	hash := sha512.Sum512(rawCfg)
+	ref.hash = hex.EncodeToString(hash[:])
+	for _, ancestor := range chain {
+		if ancestor.url == ref.url && ancestor.hash == ref.hash {
+			err := fmt.Errorf("%w: %s -> %s", errors.ErrConfigReferenceCycle, describeChain(chain), ref.name)
+			f.Logger.Crit("couldn't fetch referenced config: %v", err)
+			return types.Config{}, reference{}, err
+		}
+	}
 	if u.Scheme != "data" {
-		f.Logger.Debug("fetched referenced config at %s with SHA512: %s", *cfgRef.Source, hex.EncodeToString(hash[:]))
+		f.Logger.Debug("fetched referenced config at %s with SHA512: %s", *cfgRef.Source, ref.hash)
 	} else {
 		// data url's might contain secrets
-		f.Logger.Debug("fetched referenced config from data url with SHA512: %s", hex.EncodeToString(hash[:]))
+		f.Logger.Debug("fetched referenced config from data url with SHA512: %s", ref.hash)
 	}
 
 	if err := util.AssertValid(cfgRef.Verification, rawCfg); err != nil {
-		return types.Config{}, err
+		return types.Config{}, reference{}, err
+	}
+
+	// the verification hash covers the config as fetched, so decrypt
+	// afterward
+	rawCfg, err = providersUtil.DecryptConfig(f.Logger, rawCfg)
+	if err != nil {
+		f.Logger.Crit("couldn't decrypt referenced config: %v", err)
+		return types.Config{}, reference{}, err
 	}
 
 	cfg, r, err := config.Parse(rawCfg)
 	f.Logger.LogReport(r)
 	if err != nil {
-		return types.Config{}, err
+		return types.Config{}, reference{}, err
 	}
 
+	index := len(f.State.FetchedConfigs)
+	ref.index = &index
 	f.State.FetchedConfigs = append(f.State.FetchedConfigs, state.FetchedConfig{
 		Kind:       "user",
 		Source:     u.Path,
 		Referenced: true,
+		Parent:     chain[len(chain)-1].index,
 	})
 
-	return cfg, nil
+	return cfg, ref, nil
 }
@@ -0,0 +1,121 @@
+// Copyright 2026 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exec
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/coreos/ignition/v2/config/shared/errors"
+	cutil "github.com/coreos/ignition/v2/config/util"
+	"github.com/coreos/ignition/v2/config/v3_7_experimental/types"
+	"github.com/coreos/ignition/v2/internal/log"
+	"github.com/coreos/ignition/v2/internal/resource"
+	"github.com/coreos/ignition/v2/internal/state"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// referencingConfig returns a config which merges or replaces with the
+// configs at the given URLs.
+func referencingConfig(replace string, merge ...string) string {
+	var refs []string
+	for _, u := range merge {
+		refs = append(refs, fmt.Sprintf(`{"source": %q}`, u))
+	}
+	replaceRef := "{}"
+	if replace != "" {
+		replaceRef = fmt.Sprintf(`{"source": %q}`, replace)
+	}
+	return fmt.Sprintf(`{"ignition": {"version": "3.7.0-experimental", "config": {"replace": %s, "merge": [%s]}}}`, replaceRef, strings.Join(refs, ", "))
+}
+
+func TestRenderConfigReferences(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p := path.Clean(r.URL.Path)
+		switch {
+		case p == "/a":
+			_, _ = w.Write([]byte(referencingConfig("", server.URL+"/c")))
+		case p == "/b":
+			_, _ = w.Write([]byte(referencingConfig("", server.URL+"/c")))
+		case p == "/c":
+			_, _ = w.Write([]byte(referencingConfig("")))
+		case p == "/loop":
+			_, _ = w.Write([]byte(referencingConfig("", server.URL+"/replace-loop")))
+		case p == "/replace-loop":
+			// a different spelling of the same URL
+			_, _ = w.Write([]byte(referencingConfig(strings.Replace(server.URL, "http://", "HTTP://", 1) + "/./loop#x")))
+		case strings.HasPrefix(p, "/deep/"):
+			// different contents at each depth
+			n, _ := strconv.Atoi(strings.TrimPrefix(p, "/deep/"))
+			_, _ = w.Write([]byte(referencingConfig("", fmt.Sprintf("%s/deep/%d", server.URL, n+1))))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	render := func(cfg types.Config) (*state.State, error) {
+		logger := log.New(true)
+		st := state.State{
+			FetchedConfigs: []state.FetchedConfig{{Kind: "user", Source: "file"}},
+		}
+		parent := 0
+		f := ConfigFetcher{
+			Logger:  &logger,
+			Fetcher: &resource.Fetcher{Logger: &logger},
+			State:   &st,
+			Parent:  &parent,
+		}
+		_, err := f.RenderConfig(cfg)
+		return &st, err
+	}
+	refs := func(replace string, merge ...string) types.Config {
+		cfg := types.Config{Ignition: types.Ignition{Version: "3.7.0-experimental"}}
+		if replace != "" {
+			cfg.Ignition.Config.Replace.Source = cutil.StrToPtr(replace)
+		}
+		for _, u := range merge {
+			cfg.Ignition.Config.Merge = append(cfg.Ignition.Config.Merge, types.Resource{Source: cutil.StrToPtr(u)})
+		}
+		return cfg
+	}
+
+	// configs referenced more than once, but not by themselves, are fine
+	st, err := render(refs("", server.URL+"/a", server.URL+"/b"))
+	require.NoError(t, err)
+	assert.Equal(t, []state.FetchedConfig{
+		{Kind: "user", Source: "file"},
+		{Kind: "user", Source: "/a", Referenced: true, Parent: cutil.IntToPtr(0)},
+		{Kind: "user", Source: "/c", Referenced: true, Parent: cutil.IntToPtr(1)},
+		{Kind: "user", Source: "/b", Referenced: true, Parent: cutil.IntToPtr(0)},
+		{Kind: "user", Source: "/c", Referenced: true, Parent: cutil.IntToPtr(3)},
+	}, st.FetchedConfigs)
+
+	_, err = render(refs(server.URL + "/loop"))
+	assert.ErrorIs(t, err, errors.ErrConfigReferenceCycle)
+	assert.ErrorContains(t, err, fmt.Sprintf(`user config from "file" -> %[1]s/loop -> %[1]s/replace-loop -> http://%[2]s/./loop#x`, server.URL, strings.TrimPrefix(server.URL, "http://")))
+
+	st, err = render(refs("", server.URL+"/deep/1"))
+	assert.ErrorIs(t, err, errors.ErrConfigReferenceDepth)
+	assert.Len(t, st.FetchedConfigs, maxReferenceDepth+1)
+}
@@ -26,13 +26,18 @@ import (
 	"github.com/coreos/ignition/v2/config/shared/errors"
 	latest "github.com/coreos/ignition/v2/config/v3_7_experimental"
 	"github.com/coreos/ignition/v2/config/v3_7_experimental/types"
+	"github.com/coreos/ignition/v2/internal/distro"
 	"github.com/coreos/ignition/v2/internal/exec/stages"
 	executil "github.com/coreos/ignition/v2/internal/exec/util"
 	"github.com/coreos/ignition/v2/internal/log"
 	"github.com/coreos/ignition/v2/internal/platform"
+	"github.com/coreos/ignition/v2/internal/provenance"
 	"github.com/coreos/ignition/v2/internal/providers/cmdline"
+	"github.com/coreos/ignition/v2/internal/providers/credentials"
 	"github.com/coreos/ignition/v2/internal/providers/system"
+	"github.com/coreos/ignition/v2/internal/redact"
 	"github.com/coreos/ignition/v2/internal/resource"
+	"github.com/coreos/ignition/v2/internal/seal"
 	"github.com/coreos/ignition/v2/internal/state"
 
 	"github.com/coreos/vcontext/report"
@@ -55,14 +60,16 @@ var (
 
 // Engine represents the entity that fetches and executes a configuration.
 type Engine struct {
-	ConfigCache    string
-	FetchTimeout   time.Duration
-	Logger         *log.Logger
-	NeedNet        string
-	Root           string
-	PlatformConfig platform.Config
-	Fetcher        *resource.Fetcher
-	State          *state.State
+	ConfigCache        string
+	CacheKey           seal.Key
+	FetchTimeout       time.Duration
+	Logger             *log.Logger
+	MergeConfigSources bool
+	NeedNet            string
+	Root               string
+	PlatformConfig     platform.Config
+	Fetcher            *resource.Fetcher
+	State              *state.State
 }
 
 // Run executes the stage of the given name. It returns true if the stage
@@ -72,9 +79,7 @@ func (e Engine) Run(stageName string) error {
 		fmt.Fprintf(os.Stderr, "engine incorrectly configured\n")
 		return errors.ErrEngineConfiguration
 	}
-	baseConfig := emptyConfig
-
-	systemBaseConfig, r, err := system.FetchBaseConfig(e.Logger, e.PlatformConfig.Name())
+	systemBaseConfig, r, err := system.FetchBaseConfig(e.Logger, distro.SystemConfigDir(), e.PlatformConfig.Name())
 	e.Logger.LogReport(r)
 	if err != nil && err != platform.ErrNoProvider {
 		e.Logger.Crit("failed to acquire system base config: %v", err)
@@ -87,6 +92,19 @@ func (e Engine) Run(stageName string) error {
 		})
 	}
 
+	systemOverrideConfig, r, err := system.FetchOverrideConfig(e.Logger, distro.SystemConfigDir(), e.PlatformConfig.Name())
+	e.Logger.LogReport(r)
+	if err != nil && err != platform.ErrNoProvider {
+		e.Logger.Crit("failed to acquire system override config: %v", err)
+		return err
+	} else if err == nil {
+		e.State.FetchedConfigs = append(e.State.FetchedConfigs, state.FetchedConfig{
+			Kind:       "override",
+			Source:     "system",
+			Referenced: false,
+		})
+	}
+
 	// We special-case the fetch-offline stage a bit here: we want to be able
 	// to handle the case where the provider itself requires networking.
 	if stageName == "fetch-offline" {
@@ -107,6 +125,16 @@ func (e Engine) Run(stageName string) error {
 		return fmt.Errorf("initializing platform config: %v", err)
 	}
 
+	// If the fetch stage prefetched all remote resources, the remaining
+	// stages don't need the network. If it didn't run, because the
+	// fetch-offline stage fetched the config, the first stage after it
+	// prefetches them, since the network is up by then if the config needs
+	// it.
+	prefetch := e.State.FetchCache == nil && !strings.HasPrefix(stageName, "fetch")
+	if !prefetch && !strings.HasPrefix(stageName, "fetch") {
+		e.Fetcher.Offline = true
+	}
+
 	cfg, err := e.acquireConfig(stageName)
 	if err == resource.ErrNeedNet && stageName == "fetch-offline" {
 		err = e.signalNeedNet()
@@ -124,7 +152,24 @@ func (e Engine) Run(stageName string) error {
 	e.Logger.PushPrefix("%s", stageName)
 	defer e.Logger.PopPrefix()
 
-	fullConfig := latest.Merge(baseConfig, latest.Merge(systemBaseConfig, cfg))
+	fullConfig := MergeConfigs(systemBaseConfig, cfg, systemOverrideConfig)
+	e.Logger.Redact(redact.Secrets(fullConfig)...)
+	if err := e.acquirePlatformSSHKeys(stageName, fullConfig); err != nil {
+		e.Logger.Crit("failed to acquire platform SSH keys: %v", err)
+		return err
+	}
+	if prefetch {
+		u := executil.Util{
+			Fetcher: *e.Fetcher,
+			Logger:  e.Logger,
+			State:   e.State,
+		}
+		if err := u.PrefetchResources(fullConfig); err != nil {
+			e.Logger.Crit("failed to prefetch resources: %v", err)
+			return err
+		}
+		e.Fetcher.Offline = true
+	}
 	err = stages.Get(stageName).Create(e.Logger, e.Root, *e.Fetcher, e.State).Run(fullConfig)
 	if err == resource.ErrNeedNet && stageName == "fetch-offline" {
 		err = e.signalNeedNet()
@@ -136,7 +181,11 @@ func (e Engine) Run(stageName string) error {
 	if err != nil {
 		// e.Logger could be nil
 		fmt.Fprintf(os.Stderr, "%s failed\n", stageName)
-		tmp, jsonerr := json.MarshalIndent(fullConfig, "", "  ")
+		redactedConfig, jsonerr := redact.Config(fullConfig)
+		var tmp []byte
+		if jsonerr == nil {
+			tmp, jsonerr = json.MarshalIndent(redactedConfig, "", "  ")
+		}
 		if jsonerr != nil {
 			// Nothing else to do with this error
 			fmt.Fprintf(os.Stderr, "Could not marshal full config: %v\n", jsonerr)
@@ -149,6 +198,29 @@ func (e Engine) Run(stageName string) error {
 	return nil
 }
 
+// MergeConfigs merges the system base config, the user config, and the
+// system override config into the config the stages run. The override
+// config is merged on top, so the user config can't override it, unless it
+// is empty. Entries which delete inherited entries are dropped from the
+// result.
+func MergeConfigs(base, user, override types.Config) types.Config {
+	fullConfig := latest.Merge(emptyConfig, latest.Merge(base, user))
+	if override.Ignition.Version != "" {
+		fullConfig = latest.Merge(fullConfig, override)
+	}
+	return latest.StripDeletions(fullConfig)
+}
+
+// MergeConfigsProvenance is MergeConfigs, tracking the origins of the
+// fields of the result.
+func MergeConfigsProvenance(base, user, override provenance.Config) provenance.Config {
+	fullConfig := provenance.Merge(provenance.New("default", emptyConfig), provenance.Merge(base, user))
+	if override.Config.Ignition.Version != "" {
+		fullConfig = provenance.Merge(fullConfig, override)
+	}
+	return provenance.StripDeletions(fullConfig)
+}
+
 // logStructuredJournalEntry logs information related to
 // a user/base config into the systemd journal log.
 func logStructuredJournalEntry(cfgInfo state.FetchedConfig) error {
@@ -192,6 +264,37 @@ func (e *Engine) acquireConfig(stageName string) (cfg types.Config, err error) {
 	return
 }
 
+// acquirePlatformSSHKeys fetches the platform's SSH keys into the state if
+// any user is to be authorized with them. The keys are normally fetched by
+// the fetch stages. If fetch-offline finds the config but the keys need
+// networking, the fetch stage doesn't run, so the first stage after it
+// fetches them instead, before prefetching the config's resources.
+func (e *Engine) acquirePlatformSSHKeys(stageName string, cfg types.Config) error {
+	if e.State.PlatformSSHKeys != nil || (!strings.HasPrefix(stageName, "fetch") && e.State.FetchCache != nil) {
+		return nil
+	}
+	users, err := executil.PlatformSSHKeysUsers(cfg)
+	if err != nil {
+		return err
+	}
+	if len(users) == 0 {
+		return nil
+	}
+
+	keys, err := e.PlatformConfig.FetchSSHKeys(e.Fetcher)
+	if err == resource.ErrNeedNet && stageName == "fetch-offline" {
+		return e.signalNeedNet()
+	} else if err != nil {
+		return err
+	}
+	e.Logger.Info("fetched %d SSH keys from platform %q for users %v", len(keys), e.PlatformConfig.Name(), users)
+	if keys == nil {
+		keys = []string{}
+	}
+	e.State.PlatformSSHKeys = keys
+	return nil
+}
+
 // acquireCachedConfig returns the configuration from a local cache if
 // available
 func (e *Engine) acquireCachedConfig() (cfg types.Config, err error) {
@@ -200,13 +303,18 @@ func (e *Engine) acquireCachedConfig() (cfg types.Config, err error) {
 	if err != nil {
 		return
 	}
+	if b, err = e.CacheKey.Open(b); err != nil {
+		e.Logger.Crit("failed to decrypt cached config: %v", err)
+		return
+	}
 	if err = json.Unmarshal(b, &cfg); err != nil {
 		e.Logger.Crit("failed to parse cached config: %v", err)
 		return
 	}
 	// Create an http client and fetcher with the timeouts from the cached
 	// config
-	err = e.Fetcher.UpdateHttpTimeoutsAndCAs(cfg.Ignition.Timeouts, cfg.Ignition.Security.TLS.CertificateAuthorities, cfg.Ignition.Proxy)
+	e.Fetcher.S3Config = cfg.Ignition.S3
+	err = e.Fetcher.UpdateHttpTimeoutsAndCAs(cfg.Ignition.Timeouts, cfg.Ignition.Security.TLS, cfg.Ignition.Proxy)
 	if err != nil {
 		e.Logger.Crit("failed to update timeouts and CAs for fetcher: %v", err)
 		return
@@ -217,11 +325,7 @@ func (e *Engine) acquireCachedConfig() (cfg types.Config, err error) {
 // acquireProviderConfig attempts to fetch the configuration from the
 // provider.
 func (e *Engine) acquireProviderConfig() (cfg types.Config, err error) {
-	// Create a new http client and fetcher with the timeouts set via the flags,
-	// since we don't have a config with timeout values we can use
-	timeout := int(e.FetchTimeout.Seconds())
-	emptyProxy := types.Proxy{}
-	err = e.Fetcher.UpdateHttpTimeoutsAndCAs(types.Timeouts{HTTPTotal: &timeout}, nil, emptyProxy)
+	err = e.resetFetcher()
 	if err != nil {
 		e.Logger.Crit("failed to update timeouts and CAs for fetcher: %v", err)
 		return
@@ -244,13 +348,14 @@ func (e *Engine) acquireProviderConfig() (cfg types.Config, err error) {
 
 	// Update the http client to use the timeouts and CAs from the newly fetched
 	// config
-	err = e.Fetcher.UpdateHttpTimeoutsAndCAs(cfg.Ignition.Timeouts, cfg.Ignition.Security.TLS.CertificateAuthorities, cfg.Ignition.Proxy)
+	e.Fetcher.S3Config = cfg.Ignition.S3
+	err = e.Fetcher.UpdateHttpTimeoutsAndCAs(cfg.Ignition.Timeouts, cfg.Ignition.Security.TLS, cfg.Ignition.Proxy)
 	if err != nil {
 		e.Logger.Crit("failed to update timeouts and CAs for fetcher: %v", err)
 		return
 	}
 
-	err = e.Fetcher.RewriteCAsWithDataUrls(cfg.Ignition.Security.TLS.CertificateAuthorities)
+	err = e.Fetcher.RewriteTLSWithDataUrls(&cfg.Ignition.Security.TLS)
 	if err != nil {
 		e.Logger.Crit("error handling CAs: %v", err)
 		return
@@ -270,6 +375,10 @@ func (e *Engine) acquireProviderConfig() (cfg types.Config, err error) {
 		e.Logger.Crit("failed to marshal cached config: %v", err)
 		return
 	}
+	if b, err = e.CacheKey.Seal(b); err != nil {
+		e.Logger.Crit("failed to encrypt cached config: %v", err)
+		return
+	}
 	if err = renameio.WriteFile(e.ConfigCache, b, 0640); err != nil {
 		e.Logger.Crit("failed to write cached config: %v", err)
 		return
@@ -278,16 +387,34 @@ func (e *Engine) acquireProviderConfig() (cfg types.Config, err error) {
 	return
 }
 
+// resetFetcher creates a new http client for the fetcher with the timeouts
+// set via the flags, since we don't have a config with timeout values we can
+// use. Nothing carries over from a previous config, including its TLS
+// settings and the connections made with them.
+func (e *Engine) resetFetcher() error {
+	timeout := int(e.FetchTimeout.Seconds())
+	emptyProxy := types.Proxy{}
+	e.Fetcher.S3Config = types.S3{}
+	e.Fetcher.ResetHttpClient()
+	return e.Fetcher.UpdateHttpTimeoutsAndCAs(types.Timeouts{HTTPTotal: &timeout}, types.TLS{}, emptyProxy)
+}
+
 // fetchProviderConfig returns the externally-provided configuration. It first
 // checks to see if the command-line option is present. If so, it uses that
 // source for the configuration. If the command-line option is not present, it
 // checks for a user config in the system config dir. If that is also missing,
 // it checks the config engine's provider. An error is returned if the provider
 // is unavailable. This will also render the config (see renderConfig) before
-// returning.
+// returning. If MergeConfigSources is set, the configs of all sources are
+// merged instead (see fetchMergedProviderConfigs).
 func (e *Engine) fetchProviderConfig() (types.Config, error) {
+	if e.MergeConfigSources {
+		return e.fetchMergedProviderConfigs()
+	}
+
 	platformConfigs := []platform.Config{
 		cmdline.Config,
+		credentials.Config,
 		system.Config,
 		e.PlatformConfig,
 	}
@@ -315,17 +442,87 @@ func (e *Engine) fetchProviderConfig() (types.Config, error) {
 		Referenced: false,
 	})
 
+	return e.renderProviderConfig(cfg)
+}
+
+// fetchMergedProviderConfigs fetches the configs of all sources, rather than
+// only the first one available, and merges them in order of increasing
+// precedence: the user config in the system config dir, the config engine's
+// provider, the credentials, and the command-line option. Each config is
+// rendered on its own, so a replace directive only replaces the config of
+// its own source. errors.ErrEmpty is returned if every source is empty.
+func (e *Engine) fetchMergedProviderConfigs() (types.Config, error) {
+	platformConfigs := []platform.Config{
+		system.Config,
+		e.PlatformConfig,
+		credentials.Config,
+		cmdline.Config,
+	}
+	var merged *types.Config
+	empty := false
+	for _, platformConfig := range platformConfigs {
+		if err := e.resetFetcher(); err != nil {
+			return types.Config{}, err
+		}
+		cfg, r, err := platformConfig.Fetch(e.Fetcher, e.State)
+		e.Logger.LogReport(r)
+		if err == platform.ErrNoProvider {
+			continue
+		} else if err == errors.ErrEmpty {
+			e.Logger.Info("%v: ignoring config from %q", err, platformConfig.Name())
+			empty = true
+			continue
+		} else if err != nil {
+			return types.Config{}, err
+		}
+
+		e.State.FetchedConfigs = append(e.State.FetchedConfigs, state.FetchedConfig{
+			Kind:       "user",
+			Source:     platformConfig.Name(),
+			Referenced: false,
+		})
+
+		cfg, err = e.renderProviderConfig(cfg)
+		if err != nil {
+			return types.Config{}, err
+		}
+		if merged == nil {
+			merged = &cfg
+		} else {
+			e.Logger.Info("merging config from %q", platformConfig.Name())
+			cfg = latest.Merge(*merged, cfg)
+			merged = &cfg
+		}
+	}
+
+	if merged == nil {
+		if empty {
+			return types.Config{}, errors.ErrEmpty
+		}
+		return types.Config{}, platform.ErrNoProvider
+	}
+	return *merged, nil
+}
+
+// renderProviderConfig renders a config fetched from a provider, with the
+// fetcher configured by the config. The config must be the last one recorded
+// in State.FetchedConfigs, so the configs it references are recorded as its
+// children.
+func (e *Engine) renderProviderConfig(cfg types.Config) (types.Config, error) {
 	// Replace the HTTP client in the fetcher to be configured with the
 	// timeouts of the config
-	err = e.Fetcher.UpdateHttpTimeoutsAndCAs(cfg.Ignition.Timeouts, cfg.Ignition.Security.TLS.CertificateAuthorities, cfg.Ignition.Proxy)
+	e.Fetcher.S3Config = cfg.Ignition.S3
+	err := e.Fetcher.UpdateHttpTimeoutsAndCAs(cfg.Ignition.Timeouts, cfg.Ignition.Security.TLS, cfg.Ignition.Proxy)
 	if err != nil {
 		return types.Config{}, err
 	}
 
+	parent := len(e.State.FetchedConfigs) - 1
 	configFetcher := ConfigFetcher{
 		Logger:  e.Logger,
 		Fetcher: e.Fetcher,
 		State:   e.State,
+		Parent:  &parent,
 	}
 
 	return configFetcher.RenderConfig(cfg)
@@ -0,0 +1,83 @@
+// Copyright 2026 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exec
+
+import (
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/coreos/ignition/v2/config/v3_7_experimental/types"
+	"github.com/coreos/ignition/v2/internal/log"
+	"github.com/coreos/ignition/v2/internal/platform"
+	"github.com/coreos/ignition/v2/internal/resource"
+	"github.com/coreos/ignition/v2/internal/state"
+
+	"github.com/coreos/vcontext/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchMergedProviderConfigsResetsTLS(t *testing.T) {
+	var served atomic.Int32
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served.Add(1)
+		_, _ = w.Write([]byte(`{"ignition": {"version": "3.7.0-experimental"}}`))
+	}))
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	// the system config trusts the server, and merges a config from it
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	dir := t.TempDir()
+	userConfig := fmt.Sprintf(`{"ignition": {"version": "3.7.0-experimental", "config": {"merge": [{"source": %q}]}, "security": {"tls": {"certificateAuthorities": [{"source": "data:;base64,%s"}]}}}}`, server.URL, base64.StdEncoding.EncodeToString(ca))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "user.ign"), []byte(userConfig), 0600))
+	t.Setenv("IGNITION_SYSTEM_CONFIG_DIR", dir)
+	t.Setenv("CREDENTIALS_DIRECTORY", t.TempDir())
+
+	// the provider config, which comes next, must not
+	var providerErr error
+	provider := platform.NewConfig(platform.Provider{
+		Name: "test",
+		Fetch: func(f *resource.Fetcher) (types.Config, report.Report, error) {
+			_, providerErr = f.FetchToBuffer(*serverURL, resource.FetchOptions{})
+			return types.Config{}, report.Report{}, platform.ErrNoProvider
+		},
+	})
+
+	logger := log.New(true)
+	e := Engine{
+		FetchTimeout:       time.Second,
+		Logger:             &logger,
+		MergeConfigSources: true,
+		PlatformConfig:     provider,
+		Fetcher:            &resource.Fetcher{Logger: &logger},
+		State:              &state.State{},
+	}
+	_, err = e.fetchMergedProviderConfigs()
+	require.NoError(t, err)
+	// only the fetch which trusted the server got through
+	assert.Error(t, providerErr)
+	assert.Equal(t, int32(1), served.Load())
+}
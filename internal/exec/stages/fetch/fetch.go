@@ -37,10 +37,11 @@ func init() {
 
 type creator struct{}
 
-func (creator) Create(logger *log.Logger, root string, _ resource.Fetcher, state *state.State) stages.Stage {
+func (creator) Create(logger *log.Logger, root string, f resource.Fetcher, state *state.State) stages.Stage {
 	return &stage{
 		Util: util.Util{
 			DestDir: root,
+			Fetcher: f,
 			Logger:  logger,
 			State:   state,
 		},
@@ -63,8 +64,13 @@ func (s stage) Apply(_ types.Config, _ bool) error {
 	return nil
 }
 
-func (s stage) Run(_ types.Config) error {
-	// Nothing - all we do is fetch and allow anything else in the initramfs to run
+func (s stage) Run(config types.Config) error {
+	// The config itself has already been fetched; download any remote
+	// resources it references so that the following stages don't depend
+	// on the network staying up.
+	if err := s.PrefetchResources(config); err != nil {
+		return err
+	}
 	s.Info("fetch complete")
 	return nil
 }
@@ -130,6 +130,23 @@ func TestConfigNeedsNet(t *testing.T) {
 				},
 			},
 		},
+		// Source in an OCI registry needs Net
+		{
+			Storage: types.Storage{
+				Files: []types.File{
+					{
+						Node: types.Node{
+							Path: "/etc/motd",
+						},
+						FileEmbedded1: types.FileEmbedded1{
+							Contents: types.Resource{
+								Source: util.StrToPtr("oci://registry.example.com/repo:v1?path=/etc/motd"),
+							},
+						},
+					},
+				},
+			},
+		},
 		// CustomClevis with NeedsNetwork set to true
 		{
 			Storage: types.Storage{
@@ -17,9 +17,11 @@ package files
 import (
 	"fmt"
 	"path/filepath"
+	"slices"
 
 	"github.com/coreos/ignition/v2/config/util"
 	"github.com/coreos/ignition/v2/config/v3_7_experimental/types"
+	executil "github.com/coreos/ignition/v2/internal/exec/util"
 )
 
 func (s *stage) expandGlobList(globs ...string) ([]string, error) {
@@ -101,13 +103,21 @@ func (s *stage) createPasswd(config types.Config) error {
 // ensureUsers ensures that users match the state described
 // in config.Passwd.Users.
 func (s stage) ensureUsers(config types.Config) error {
-	if len(config.Passwd.Users) == 0 {
+	platformUsers, err := executil.PlatformSSHKeysUsers(config)
+	if err != nil {
+		return err
+	}
+	if len(config.Passwd.Users) == 0 && len(platformUsers) == 0 {
 		return nil
 	}
 	s.PushPrefix("ensureUsers")
 	defer s.PopPrefix()
 
 	for _, u := range config.Passwd.Users {
+		if slices.Contains(platformUsers, u.Name) {
+			u.SSHAuthorizedKeys = s.withPlatformSSHKeys(u.SSHAuthorizedKeys)
+		}
+
 		if err := s.EnsureUser(u); err != nil {
 			return fmt.Errorf("failed to create user %q: %v",
 				u.Name, err)
@@ -133,9 +143,36 @@ func (s stage) ensureUsers(config types.Config) error {
 		}
 	}
 
+	// users named on the kernel command line must already exist
+	for _, name := range platformUsers {
+		if slices.ContainsFunc(config.Passwd.Users, func(u types.PasswdUser) bool { return u.Name == name }) {
+			continue
+		}
+		u := types.PasswdUser{
+			Name:              name,
+			SSHAuthorizedKeys: s.withPlatformSSHKeys(nil),
+		}
+		if err := s.AuthorizeSSHKeys(u); err != nil {
+			return fmt.Errorf("failed to add keys to user %q: %v",
+				u.Name, err)
+		}
+	}
+
 	return nil
 }
 
+// withPlatformSSHKeys returns keys followed by the platform's SSH keys
+// which aren't already among them.
+func (s stage) withPlatformSSHKeys(keys []types.SSHAuthorizedKey) []types.SSHAuthorizedKey {
+	ret := slices.Clone(keys)
+	for _, key := range s.State.PlatformSSHKeys {
+		if !slices.Contains(ret, types.SSHAuthorizedKey(key)) {
+			ret = append(ret, types.SSHAuthorizedKey(key))
+		}
+	}
+	return ret
+}
+
 // ensureGroups ensures that groups match the state described
 // in config.Passwd.Groups.
 func (s stage) ensureGroups(config types.Config) error {
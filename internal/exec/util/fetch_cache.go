@@ -0,0 +1,144 @@
+// Copyright 2026 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/coreos/ignition/v2/config/v3_7_experimental/types"
+	"github.com/coreos/ignition/v2/internal/distro"
+	"github.com/coreos/ignition/v2/internal/util"
+)
+
+// cacheKey returns the key under which the contents fetched by f are stored
+// in the fetch cache. Resources are keyed by URL, compression, expected sum
+// and HTTP headers, so two references to the same URL with different
+// verification hashes or headers are cached separately.
+func (f FetchOp) cacheKey() string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%s\n%x\n", f.Url.String(), f.FetchOptions.Compression, f.FetchOptions.ExpectedSum)
+	headers := make(map[string][]string, len(f.FetchOptions.Headers))
+	for name, values := range f.FetchOptions.Headers {
+		name = http.CanonicalHeaderKey(name)
+		headers[name] = append(headers[name], values...)
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(h, "%s: %q\n", name, headers[name])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// PrefetchResources downloads the remote contents of files and LUKS
+// keyfiles into the fetch cache. CA bundles don't need to be handled here
+// since they are already inlined into the cached config as data URLs.
+func (u Util) PrefetchResources(config types.Config) error {
+	dir := distro.FetchCacheDir()
+	for _, f := range config.Storage.Files {
+		resources := append([]types.Resource{f.Contents}, f.Append...)
+		for _, res := range resources {
+			if err := u.Prefetch(dir, res); err != nil {
+				return fmt.Errorf("prefetching contents of %q: %w", f.Path, err)
+			}
+		}
+	}
+	for _, luks := range config.Storage.Luks {
+		if err := u.Prefetch(dir, luks.KeyFile); err != nil {
+			return fmt.Errorf("prefetching keyfile of %q: %w", luks.Name, err)
+		}
+	}
+	// Record that the cache is complete even if it's empty, so the
+	// following stages know they can run offline.
+	if u.State.FetchCache == nil {
+		u.State.FetchCache = make(map[string]string)
+	}
+	return nil
+}
+
+// Prefetch fetches contents into the cache directory dir and records the
+// result in the state so that a later PerformFetch of the same resource can
+// be satisfied without the network. Resources which can be fetched offline
+// (e.g. data URLs) are skipped.
+func (u Util) Prefetch(dir string, contents types.Resource) error {
+	if contents.Source == nil || *contents.Source == "" {
+		return nil
+	}
+	op, err := newFetchOp(u.Logger, types.Node{}, contents)
+	if err != nil {
+		return err
+	}
+	if !util.UrlNeedsNet(op.Url) {
+		return nil
+	}
+	key := op.cacheKey()
+	if _, ok := u.State.FetchCache[key]; ok {
+		return nil
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, "tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+	}()
+
+	if err := u.Fetcher.Fetch(op.Url, tmp, op.FetchOptions); err != nil {
+		return err
+	}
+	path := filepath.Join(dir, key)
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	if u.State.FetchCache == nil {
+		u.State.FetchCache = make(map[string]string)
+	}
+	u.State.FetchCache[key] = path
+	return nil
+}
+
+// fetchFromCache copies the cached contents of f into dest. It returns false
+// if the resource was not prefetched.
+func (u Util) fetchFromCache(f FetchOp, dest io.Writer) (bool, error) {
+	path, ok := u.State.FetchCache[f.cacheKey()]
+	if !ok {
+		return false, nil
+	}
+	src, err := os.Open(path)
+	if err != nil {
+		return true, err
+	}
+	defer func() {
+		_ = src.Close()
+	}()
+	u.Debug("using prefetched contents for %q", f.Url.String())
+	_, err = io.Copy(dest, src)
+	return true, err
+}
@@ -0,0 +1,117 @@
+// Copyright 2026 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	cutil "github.com/coreos/ignition/v2/config/util"
+	"github.com/coreos/ignition/v2/config/v3_7_experimental/types"
+	"github.com/coreos/ignition/v2/internal/log"
+	"github.com/coreos/ignition/v2/internal/resource"
+	"github.com/coreos/ignition/v2/internal/state"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrefetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("hello world\n"))
+	}))
+
+	logger := log.New(true)
+	u := Util{
+		Fetcher: resource.Fetcher{Logger: &logger},
+		Logger:  &logger,
+		State:   &state.State{},
+	}
+	cacheDir := t.TempDir()
+
+	remote := types.Resource{
+		Source: cutil.StrToPtr(server.URL + "/file"),
+	}
+	verified := types.Resource{
+		Source:       cutil.StrToPtr(server.URL + "/file"),
+		Verification: types.Verification{Hash: cutil.StrToPtr("sha512-db3974a97f2407b7cae1ae637c0030687a11913274d578492558e39c16c017de84eacdc8c62fe34ee4e12b4b1428817f09b6a2760c3f8a664ceae94d2434a593")},
+	}
+	inline := types.Resource{
+		Source: cutil.StrToPtr("data:,inline"),
+	}
+	for _, res := range []types.Resource{remote, verified, inline, {}} {
+		assert.NoError(t, u.Prefetch(cacheDir, res))
+	}
+	// the data URL and the empty resource are not cached
+	assert.Len(t, u.State.FetchCache, 2)
+
+	// later stages run offline and are served from the cache
+	server.Close()
+	u.Fetcher.Offline = true
+	destDir := t.TempDir()
+	for i, res := range []types.Resource{remote, verified, inline} {
+		path := filepath.Join(destDir, string(rune('a'+i)))
+		ops, err := u.PrepareFetches(&logger, types.File{
+			Node:          types.Node{Path: path},
+			FileEmbedded1: types.FileEmbedded1{Contents: res},
+		})
+		assert.NoError(t, err)
+		for _, op := range ops {
+			assert.NoError(t, u.PerformFetch(op))
+		}
+		data, err := os.ReadFile(path)
+		assert.NoError(t, err)
+		if i == 2 {
+			assert.Equal(t, "inline", string(data))
+		} else {
+			assert.Equal(t, "hello world\n", string(data))
+		}
+	}
+
+	// resources which weren't prefetched still need the network
+	other, err := url.Parse(server.URL + "/other")
+	assert.NoError(t, err)
+	err = u.PerformFetch(FetchOp{
+		Url:  *other,
+		Node: types.Node{Path: filepath.Join(destDir, "other")},
+	})
+	assert.Equal(t, resource.ErrNeedNet, err)
+}
+
+func TestCacheKeyHeaders(t *testing.T) {
+	u, err := url.Parse("https://example.com/file")
+	assert.NoError(t, err)
+	key := func(headers http.Header) string {
+		return FetchOp{
+			Url:          *u,
+			FetchOptions: resource.FetchOptions{Headers: headers},
+		}.cacheKey()
+	}
+
+	plain := key(nil)
+	auth := key(http.Header{"Authorization": {"Bearer a"}})
+	assert.NotEqual(t, plain, auth)
+	assert.NotEqual(t, auth, key(http.Header{"Authorization": {"Bearer b"}}))
+	assert.NotEqual(t, auth, key(http.Header{"Accept": {"Bearer a"}}))
+	// header names are compared case-insensitively and in any order
+	assert.Equal(t, auth, key(http.Header{"authorization": {"Bearer a"}}))
+	assert.Equal(t,
+		key(http.Header{"Accept": {"text/plain"}, "Authorization": {"Bearer a"}}),
+		key(http.Header{"Authorization": {"Bearer a"}, "Accept": {"text/plain"}}))
+	assert.Equal(t, plain, key(http.Header{}))
+}
@@ -94,6 +94,7 @@ func newFetchOp(l *log.Logger, node types.Node, contents types.Resource) (FetchO
 			Compression: compression,
 			ExpectedSum: expectedSum,
 			Headers:     headers,
+			Retry:       contents.Retry,
 		},
 	}, nil
 }
@@ -194,7 +195,9 @@ func ToFileMode(m int) os.FileMode {
 }
 
 // PerformFetch performs a fetch operation generated by PrepareFetch, retrieving
-// the file and writing it to disk. Any encountered errors are returned.
+// the file and writing it to disk. If the fetch stage already downloaded the
+// resource into the fetch cache, the cached copy is used instead. Any
+// encountered errors are returned.
 func (u Util) PerformFetch(f FetchOp) error {
 	path := f.Node.Path
 
@@ -222,7 +225,10 @@ func (u Util) PerformFetch(f FetchOp) error {
 		_ = os.Remove(tmp.Name())
 	}()
 
-	err = u.Fetcher.Fetch(f.Url, tmp, f.FetchOptions)
+	cached, err := u.fetchFromCache(f, tmp)
+	if !cached {
+		err = u.Fetcher.Fetch(f.Url, tmp, f.FetchOptions)
+	}
 	if err != nil {
 		u.Crit("Error fetching file %q: %v", path, err)
 		return err
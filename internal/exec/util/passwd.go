@@ -20,6 +20,7 @@ import (
 	"os/exec"
 	"os/user"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -35,6 +36,10 @@ const (
 	// ignitionSSHAuthorizedkeysMessageID keeps track of the journald
 	// log related to ssh_authorized_keys information.
 	ignitionSSHAuthorizedkeysMessageID = "225067b87bbd4a0cb6ab151f82fa364b"
+
+	// platformSSHKeysUserFlag names a user to authorize with the
+	// platform's SSH keys, in addition to any in the config.
+	platformSSHKeysUserFlag = "ignition.platform.ssh_keys_user"
 )
 
 func appendIfTrue(args []string, test *bool, newargs string) []string {
@@ -276,6 +281,33 @@ func (u Util) AuthorizeSSHKeys(c types.PasswdUser) error {
 	}, "adding ssh keys to user %q", c.Name)
 }
 
+// PlatformSSHKeysUsers returns the names of the users to authorize with the
+// platform's SSH keys: those with platformSSHKeys set in the config, then the
+// user named by the ignition.platform.ssh_keys_user kernel argument.
+func PlatformSSHKeysUsers(config types.Config) ([]string, error) {
+	cmdline, err := os.ReadFile(distro.KernelCmdlinePath())
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("reading kernel command line: %w", err)
+	}
+	return platformSSHKeysUsers(config, cmdline), nil
+}
+
+func platformSSHKeysUsers(config types.Config, cmdline []byte) []string {
+	var names []string
+	for _, user := range config.Passwd.Users {
+		if util.IsTrue(user.PlatformSSHKeys) && !util.IsFalse(user.ShouldExist) {
+			names = append(names, user.Name)
+		}
+	}
+	for _, arg := range strings.Fields(string(cmdline)) {
+		key, value, _ := strings.Cut(arg, "=")
+		if key == platformSSHKeysUserFlag && value != "" && !slices.Contains(names, value) {
+			names = append(names, value)
+		}
+	}
+	return names
+}
+
 // golang--
 func translateV2_1SSHAuthorizedKeySliceToStringSlice(keys []types.SSHAuthorizedKey) []string {
 	newKeys := make([]string, len(keys))
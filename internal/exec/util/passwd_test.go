@@ -0,0 +1,69 @@
+// Copyright 2026 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"testing"
+
+	"github.com/coreos/ignition/v2/config/util"
+	"github.com/coreos/ignition/v2/config/v3_7_experimental/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlatformSSHKeysUsers(t *testing.T) {
+	users := []types.PasswdUser{
+		{Name: "core", PlatformSSHKeys: util.BoolToPtr(true)},
+		{Name: "alice"},
+		{Name: "bob", PlatformSSHKeys: util.BoolToPtr(false)},
+		{Name: "gone", PlatformSSHKeys: util.BoolToPtr(true), ShouldExist: util.BoolToPtr(false)},
+	}
+
+	tests := []struct {
+		users   []types.PasswdUser
+		cmdline string
+		out     []string
+	}{
+		{
+			cmdline: "root=/dev/sda1 quiet",
+			out:     nil,
+		},
+		{
+			users:   users,
+			cmdline: "root=/dev/sda1 quiet",
+			out:     []string{"core"},
+		},
+		{
+			users:   users,
+			cmdline: "quiet ignition.platform.ssh_keys_user=alice\n",
+			out:     []string{"core", "alice"},
+		},
+		{
+			// a user shouldn't be listed twice
+			users:   users,
+			cmdline: "ignition.platform.ssh_keys_user=core",
+			out:     []string{"core"},
+		},
+		{
+			cmdline: "ignition.platform.ssh_keys_user= ignition.platform.ssh_keys_user",
+			out:     nil,
+		},
+	}
+
+	for i, test := range tests {
+		config := types.Config{Passwd: types.Passwd{Users: test.users}}
+		assert.Equal(t, test.out, platformSSHKeysUsers(config, []byte(test.cmdline)), "#%d", i)
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"log/syslog"
 	"os/exec"
+	"slices"
 	"strings"
 
 	"github.com/coreos/vcontext/report"
@@ -41,13 +42,25 @@ type Logger struct {
 	ops           LoggerOps
 	prefixStack   []string
 	opSequenceNum int
+	// secrets is shared by copies of the logger, so that secrets
+	// registered through any of them are redacted by all
+	secrets *[]string
 }
 
+const (
+	// redacted replaces secrets in messages
+	redacted = "<redacted>"
+
+	// MinSecretLength is the length below which secrets aren't redacted,
+	// since replacing them would mangle unrelated text
+	MinSecretLength = 6
+)
+
 // New creates a new logger.
 // If logToStdout is true, syslog is tried first. If syslog fails or logToStdout
 // is false Stdout is used.
 func New(logToStdout bool) Logger {
-	logger := Logger{}
+	logger := Logger{secrets: &[]string{}}
 	if !logToStdout {
 		var err error
 		logger.ops, err = syslog.New(syslog.LOG_DEBUG, "ignition")
@@ -61,6 +74,14 @@ func New(logToStdout bool) Logger {
 	return logger
 }
 
+// NewStderr creates a new logger which logs to stderr.
+func NewStderr() Logger {
+	return Logger{
+		ops:     Stderr{},
+		secrets: &[]string{},
+	}
+}
+
 // Close closes the logger. Ignore errors.
 func (l Logger) Close() {
 	_ = l.ops.Close()
@@ -189,6 +210,27 @@ func (l Logger) LogReport(r report.Report) {
 	}
 }
 
+// Redact arranges for the secrets to be replaced in all further messages.
+func (l *Logger) Redact(secrets ...string) {
+	if l.secrets == nil {
+		l.secrets = &[]string{}
+	}
+	for _, secret := range secrets {
+		if secret == "" || slices.Contains(*l.secrets, secret) {
+			continue
+		}
+		if len(secret) < MinSecretLength {
+			l.Warning("not redacting a secret shorter than %d characters from the logs", MinSecretLength)
+			continue
+		}
+		*l.secrets = append(*l.secrets, secret)
+	}
+	// replace longer secrets first, in case one contains another
+	slices.SortStableFunc(*l.secrets, func(a, b string) int {
+		return len(b) - len(a)
+	})
+}
+
 // logStart logs the start of a multi-step/substantial/time-consuming operation.
 func (l Logger) logStart(format string, a ...interface{}) {
 	l.Info(fmt.Sprintf("[started]  %s", format), a...)
@@ -216,5 +258,16 @@ func (l Logger) sprintf(format string, a ...interface{}) string {
 		m = append(m, fmt.Sprintf("%s:", pfx))
 	}
 	m = append(m, fmt.Sprintf(format, a...))
-	return strings.Join(m, " ")
+	return l.redact(strings.Join(m, " "))
+}
+
+// redact replaces the registered secrets in the message.
+func (l Logger) redact(msg string) string {
+	if l.secrets == nil {
+		return msg
+	}
+	for _, secret := range *l.secrets {
+		msg = strings.ReplaceAll(msg, secret, redacted)
+	}
+	return msg
 }
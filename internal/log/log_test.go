@@ -0,0 +1,52 @@
+// Copyright 2026 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// warnings records the warnings logged through it
+type warnings struct {
+	Stdout
+	logged []string
+}
+
+func (w *warnings) Warning(msg string) error {
+	w.logged = append(w.logged, msg)
+	return nil
+}
+
+func TestRedact(t *testing.T) {
+	logger := New(true)
+	ops := &warnings{}
+	logger.ops = ops
+	// copies share the registered secrets
+	copied := logger
+	logger.PushPrefix("files")
+
+	logger.Redact("Bearer token1", "short", "token1-extended", "")
+	copied.Redact("Bearer token1")
+
+	assert.Equal(t, "files: fetching with header Authorization: <redacted>", logger.sprintf("fetching with header Authorization: %s", "Bearer token1"))
+	assert.Equal(t, "GET https://example.com/?t=<redacted>: 404", copied.sprintf("GET https://example.com/?t=%s: 404", "token1-extended"))
+	// short values aren't secrets worth mangling messages over
+	assert.Equal(t, "short", copied.sprintf("short"))
+	assert.Equal(t, 2, len(*logger.secrets))
+	// but skipping them is logged, without the value
+	assert.Equal(t, []string{"files: not redacting a secret shorter than 6 characters from the logs"}, ops.logged)
+}
@@ -0,0 +1,33 @@
+// Copyright 2026 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"fmt"
+	"os"
+)
+
+// Stderr logs to stderr, for commands which write their output to stdout.
+type Stderr struct{}
+
+func (Stderr) Emerg(msg string) error   { fmt.Fprintln(os.Stderr, "EMERGENCY:", msg); return nil }
+func (Stderr) Alert(msg string) error   { fmt.Fprintln(os.Stderr, "ALERT    :", msg); return nil }
+func (Stderr) Crit(msg string) error    { fmt.Fprintln(os.Stderr, "CRITICAL :", msg); return nil }
+func (Stderr) Err(msg string) error     { fmt.Fprintln(os.Stderr, "ERROR    :", msg); return nil }
+func (Stderr) Warning(msg string) error { fmt.Fprintln(os.Stderr, "WARNING  :", msg); return nil }
+func (Stderr) Notice(msg string) error  { fmt.Fprintln(os.Stderr, "NOTICE   :", msg); return nil }
+func (Stderr) Info(msg string) error    { fmt.Fprintln(os.Stderr, "INFO     :", msg); return nil }
+func (Stderr) Debug(msg string) error   { fmt.Fprintln(os.Stderr, "DEBUG    :", msg); return nil }
+func (Stderr) Close() error             { return nil }
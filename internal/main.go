@@ -15,6 +15,8 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
@@ -24,11 +26,15 @@ import (
 
 	"github.com/coreos/ignition/v2/config"
 	"github.com/coreos/ignition/v2/internal/apply"
+	"github.com/coreos/ignition/v2/internal/distro"
 	"github.com/coreos/ignition/v2/internal/exec"
 	"github.com/coreos/ignition/v2/internal/exec/stages"
 	"github.com/coreos/ignition/v2/internal/log"
+	"github.com/coreos/ignition/v2/internal/merge"
 	"github.com/coreos/ignition/v2/internal/platform"
+	"github.com/coreos/ignition/v2/internal/platform/detect"
 	_ "github.com/coreos/ignition/v2/internal/register"
+	"github.com/coreos/ignition/v2/internal/seal"
 	"github.com/coreos/ignition/v2/internal/state"
 	"github.com/coreos/ignition/v2/internal/version"
 	"github.com/spf13/pflag"
@@ -40,6 +46,8 @@ func main() {
 		ignitionApplyMain()
 	case "ignition-rmcfg":
 		ignitionRmCfgMain()
+	case "ignition-merge":
+		ignitionMergeMain()
 	default:
 		// assume regular Ignition
 		ignitionMain()
@@ -49,7 +57,9 @@ func main() {
 func ignitionMain() {
 	flags := struct {
 		configCache  string
+		encryptCache bool
 		fetchTimeout time.Duration
+		mergeSources bool
 		needNet      string
 		platform     platform.Name
 		root         string
@@ -60,9 +70,11 @@ func ignitionMain() {
 	}{}
 
 	flag.StringVar(&flags.configCache, "config-cache", "/run/ignition.json", "where to cache the config")
+	flag.BoolVar(&flags.encryptCache, "encrypt-cache", false, "encrypt the config cache and state with a key in the kernel keyring")
 	flag.DurationVar(&flags.fetchTimeout, "fetch-timeout", exec.DefaultFetchTimeout, "initial duration for which to wait for config")
+	flag.BoolVar(&flags.mergeSources, "merge-config-sources", false, "merge the configs of all config sources instead of using the first one found")
 	flag.StringVar(&flags.needNet, "neednet", "/run/ignition/neednet", "flag file to write from fetch-offline if networking is needed")
-	flag.Var(&flags.platform, "platform", fmt.Sprintf("current platform. %v", platform.Names()))
+	flag.Var(&flags.platform, "platform", fmt.Sprintf("current platform, detected if omitted. %v", platform.Names()))
 	flag.StringVar(&flags.root, "root", "/", "root of the filesystem")
 	flag.Var(&flags.stage, "stage", fmt.Sprintf("execution stage. %v", stages.Names()))
 	flag.StringVar(&flags.stateFile, "state-file", "/run/ignition/state", "where to store internal state")
@@ -76,11 +88,6 @@ func ignitionMain() {
 		return
 	}
 
-	if flags.platform == "" {
-		fmt.Fprint(os.Stderr, "'--platform' must be provided\n")
-		os.Exit(2)
-	}
-
 	if flags.stage == "" {
 		fmt.Fprint(os.Stderr, "'--stage' must be provided\n")
 		os.Exit(2)
@@ -92,26 +99,51 @@ func ignitionMain() {
 	logger.Info("%s", version.String)
 	logger.Info("Stage: %v", flags.stage)
 
-	platformConfig := platform.MustGet(flags.platform.String())
-	fetcher, err := platformConfig.NewFetcher(&logger)
+	// the key is shared by all stages through the kernel keyring
+	var cacheKey seal.Key
+	var err error
+	if flags.encryptCache {
+		cacheKey, err = seal.GetKey(flags.stateFile)
+		if errors.Is(err, seal.ErrUnavailable) {
+			// a cache sealed by an earlier stage will still fail to
+			// load, but a plaintext one works
+			logger.Warning("keeping config cache and state in plaintext: %v", err)
+		} else if err != nil {
+			logger.Crit("getting key for config cache: %v", err)
+			os.Exit(3)
+		}
+	}
+	state, err := state.Load(flags.stateFile, cacheKey)
 	if err != nil {
-		logger.Crit("failed to generate fetcher: %s", err)
+		logger.Crit("reading state: %s", err)
 		os.Exit(3)
 	}
-	state, err := state.Load(flags.stateFile)
+	if flags.platform == "" {
+		if _, ok := platform.Get(state.Platform); ok {
+			logger.Info("using platform %q detected by an earlier stage", state.Platform)
+			flags.platform = platform.Name(state.Platform)
+		} else {
+			flags.platform = platform.Name(detect.Platform(&logger))
+			state.Platform = flags.platform.String()
+		}
+	}
+	platformConfig := platform.MustGet(flags.platform.String())
+	fetcher, err := platformConfig.NewFetcher(&logger)
 	if err != nil {
-		logger.Crit("reading state: %s", err)
+		logger.Crit("failed to generate fetcher: %s", err)
 		os.Exit(3)
 	}
 	engine := exec.Engine{
-		Root:           flags.root,
-		FetchTimeout:   flags.fetchTimeout,
-		Logger:         &logger,
-		NeedNet:        flags.needNet,
-		ConfigCache:    flags.configCache,
-		PlatformConfig: platformConfig,
-		Fetcher:        &fetcher,
-		State:          &state,
+		Root:               flags.root,
+		FetchTimeout:       flags.fetchTimeout,
+		Logger:             &logger,
+		MergeConfigSources: flags.mergeSources,
+		NeedNet:            flags.needNet,
+		ConfigCache:        flags.configCache,
+		CacheKey:           cacheKey,
+		PlatformConfig:     platformConfig,
+		Fetcher:            &fetcher,
+		State:              &state,
 	}
 
 	err = engine.Run(flags.stage.String())
@@ -122,10 +154,25 @@ func ignitionMain() {
 		logger.Crit("Ignition failed: %v", err.Error())
 		os.Exit(1)
 	}
-	if err := engine.State.Save(flags.stateFile); err != nil {
+	// umount is the last stage, run as the initramfs switches to the real
+	// root. Remove the fetch cache, which holds file contents and LUKS key
+	// files in plaintext, and revoke the key so the cache and state can't
+	// be read afterward.
+	if flags.stage == "umount" {
+		if err := os.RemoveAll(distro.FetchCacheDir()); err != nil {
+			logger.Err("removing fetch cache: %v", err)
+		}
+		engine.State.FetchCache = nil
+	}
+	if err := engine.State.Save(flags.stateFile, cacheKey); err != nil {
 		logger.Crit("writing state: %v", err)
 		os.Exit(1)
 	}
+	if flags.stage == "umount" && cacheKey != nil {
+		if err := seal.RevokeKey(flags.stateFile); err != nil {
+			logger.Err("revoking key for config cache: %v", err)
+		}
+	}
 	logger.Info("Ignition finished successfully")
 }
 
@@ -191,7 +238,7 @@ func ignitionRmCfgMain() {
 		platform    string
 		version     bool
 	}{}
-	pflag.StringVar(&flags.platform, "platform", "", fmt.Sprintf("current platform. %v", platform.Names()))
+	pflag.StringVar(&flags.platform, "platform", "", fmt.Sprintf("current platform, detected if omitted. %v", platform.Names()))
 	pflag.BoolVar(&flags.logToStdout, "log-to-stdout", false, "log to stdout instead of the system log")
 	pflag.BoolVar(&flags.version, "version", false, "print the version and exit")
 	pflag.Usage = func() {
@@ -211,16 +258,14 @@ func ignitionRmCfgMain() {
 		os.Exit(2)
 	}
 
-	if flags.platform == "" {
-		fmt.Fprint(os.Stderr, "'--platform' must be provided\n")
-		os.Exit(2)
-	}
-
 	logger := log.New(flags.logToStdout)
 	defer logger.Close()
 
 	logger.Info("%s", version.String)
 
+	if flags.platform == "" {
+		flags.platform = detect.Platform(&logger)
+	}
 	platformConfig := platform.MustGet(flags.platform)
 	fetcher, err := platformConfig.NewFetcher(&logger)
 	if err != nil {
@@ -235,3 +280,87 @@ func ignitionRmCfgMain() {
 
 	logger.Info("Successfully deleted config")
 }
+
+func ignitionMergeMain() {
+	printVersion := false
+	output := ""
+	provenanceFile := ""
+	flags := merge.Flags{}
+	pflag.BoolVar(&printVersion, "version", false, "print the version of ignition-merge")
+	pflag.StringVar(&flags.SystemConfigDir, "system-config-dir", distro.SystemConfigDir(), "directory of the base and override config fragments")
+	pflag.StringVar(&flags.Platform, "platform", "", fmt.Sprintf("platform whose config fragments to merge, none if omitted. %v", platform.Names()))
+	pflag.BoolVar(&flags.Offline, "offline", false, "error out if configs reference remote configs")
+	pflag.StringVarP(&output, "output", "o", "-", "where to write the merged config")
+	pflag.StringVar(&provenanceFile, "provenance", "", "where to write the input config each field of the merged config came from")
+	pflag.Usage = func() {
+		_, _ = fmt.Fprintf(pflag.CommandLine.Output(), "Usage: %s [options] config.ign...\n", os.Args[0])
+		_, _ = fmt.Fprintf(pflag.CommandLine.Output(), "Options:\n")
+		pflag.PrintDefaults()
+	}
+	pflag.Parse()
+
+	if printVersion {
+		fmt.Printf("%s\n", version.String)
+		return
+	}
+
+	if pflag.NArg() == 0 {
+		pflag.Usage()
+		os.Exit(1)
+	}
+
+	// the merged config may be written to stdout
+	logger := log.NewStderr()
+	defer logger.Close()
+
+	logger.Info("%s", version.String)
+
+	var inputs []merge.Input
+	for _, cfgArg := range pflag.Args() {
+		var blob []byte
+		var err error
+		name := cfgArg
+		if cfgArg == "-" {
+			name = "stdin"
+			blob, err = io.ReadAll(os.Stdin)
+		} else {
+			blob, err = os.ReadFile(cfgArg)
+		}
+		if err != nil {
+			logger.Crit("couldn't read config: %v", err)
+			os.Exit(1)
+		}
+		inputs = append(inputs, merge.Input{Name: name, Raw: blob})
+	}
+
+	cfg, err := merge.Run(inputs, flags, &logger)
+	if err != nil {
+		logger.Crit("failed to merge: %v", err)
+		os.Exit(1)
+	}
+
+	b, err := json.MarshalIndent(cfg.Config, "", "  ")
+	if err != nil {
+		logger.Crit("failed to marshal merged config: %v", err)
+		os.Exit(1)
+	}
+	b = append(b, '\n')
+	if output == "-" {
+		_, err = os.Stdout.Write(b)
+	} else {
+		// the config may contain secrets
+		err = os.WriteFile(output, b, 0600)
+	}
+	if err != nil {
+		logger.Crit("failed to write merged config: %v", err)
+		os.Exit(1)
+	}
+
+	if provenanceFile != "" {
+		// the URLs of referenced configs may contain tokens
+		if err := os.WriteFile(provenanceFile, []byte(cfg.String()+"\n"), 0600); err != nil {
+			logger.Crit("failed to write provenance: %v", err)
+			os.Exit(1)
+		}
+	}
+}
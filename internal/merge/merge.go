@@ -0,0 +1,120 @@
+// Copyright 2026 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge
+
+import (
+	"fmt"
+
+	"github.com/coreos/ignition/v2/config/shared/errors"
+	"github.com/coreos/ignition/v2/internal/exec"
+	"github.com/coreos/ignition/v2/internal/log"
+	"github.com/coreos/ignition/v2/internal/platform"
+	"github.com/coreos/ignition/v2/internal/provenance"
+	"github.com/coreos/ignition/v2/internal/providers/system"
+	"github.com/coreos/ignition/v2/internal/providers/util"
+	"github.com/coreos/ignition/v2/internal/resource"
+	"github.com/coreos/ignition/v2/internal/state"
+
+	"github.com/coreos/vcontext/validate"
+)
+
+type Flags struct {
+	// SystemConfigDir is the directory of the base and override config
+	// fragments.
+	SystemConfigDir string
+	// Platform selects the platform specific config fragments. If empty,
+	// only the fragments common to all platforms are read.
+	Platform string
+	Offline  bool
+}
+
+// Input is a user config, along with the name of its source.
+type Input struct {
+	Name string
+	Raw  []byte
+}
+
+// Run merges the system base config, the user configs, and the system
+// override config as Ignition does, fetching and merging the configs they
+// reference. The user configs are merged in order, each rendered on its own
+// as Ignition renders the config of each config source. The fields of the
+// merged config originate from the inputs, the config fragments, and the
+// referenced configs.
+func Run(inputs []Input, flags Flags, logger *log.Logger) (provenance.Config, error) {
+	baseConfig, r, err := system.FetchBaseConfigProvenance(logger, flags.SystemConfigDir, flags.Platform)
+	logger.LogReport(r)
+	if err != nil && err != platform.ErrNoProvider {
+		return provenance.Config{}, fmt.Errorf("reading base config: %w", err)
+	}
+
+	overrideConfig, r, err := system.FetchOverrideConfigProvenance(logger, flags.SystemConfigDir, flags.Platform)
+	logger.LogReport(r)
+	if err != nil && err != platform.ErrNoProvider {
+		return provenance.Config{}, fmt.Errorf("reading override config: %w", err)
+	}
+
+	fetcher := resource.Fetcher{
+		Logger:  logger,
+		Offline: flags.Offline,
+	}
+	st := state.State{}
+
+	var userConfig provenance.Config
+	for _, input := range inputs {
+		cfg, r, err := util.ParseConfig(logger, input.Raw)
+		logger.LogReport(r)
+		if err == errors.ErrEmpty {
+			logger.Info("%v: ignoring config from %q", err, input.Name)
+			continue
+		} else if err != nil {
+			return provenance.Config{}, fmt.Errorf("parsing config from %q: %w", input.Name, err)
+		}
+
+		// Configure the fetcher with the timeouts and CAs of the
+		// config, as Ignition does for the config of a config source
+		fetcher.S3Config = cfg.Ignition.S3
+		err = fetcher.UpdateHttpTimeoutsAndCAs(cfg.Ignition.Timeouts, cfg.Ignition.Security.TLS, cfg.Ignition.Proxy)
+		if err != nil {
+			return provenance.Config{}, err
+		}
+
+		st.FetchedConfigs = append(st.FetchedConfigs, state.FetchedConfig{
+			Kind:   "user",
+			Source: input.Name,
+		})
+		parent := len(st.FetchedConfigs) - 1
+		cfgFetcher := exec.ConfigFetcher{
+			Logger:          logger,
+			Fetcher:         &fetcher,
+			State:           &st,
+			Parent:          &parent,
+			TrackProvenance: true,
+		}
+		renderedConfig, err := cfgFetcher.RenderConfigProvenance(provenance.New(input.Name, cfg))
+		if err != nil {
+			return provenance.Config{}, err
+		}
+
+		userConfig = provenance.Merge(userConfig, renderedConfig)
+	}
+
+	fullConfig := exec.MergeConfigsProvenance(baseConfig, userConfig, overrideConfig)
+	rpt := validate.Validate(fullConfig.Config, "json")
+	logger.LogReport(rpt)
+	if rpt.IsFatal() {
+		return provenance.Config{}, errors.ErrInvalid
+	}
+	return fullConfig, nil
+}
@@ -0,0 +1,96 @@
+// Copyright 2026 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/coreos/ignition/v2/internal/log"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	writeFragment := func(name, contents string) {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(contents), 0644))
+	}
+	writeFragment("base.d/10-base.ign", `{
+		"ignition": {"version": "3.4.0"},
+		"storage": {"files": [
+			{"path": "/etc/base", "contents": {"source": "data:,base"}},
+			{"path": "/etc/removed", "contents": {"source": "data:,removed"}}
+		]}
+	}`)
+	writeFragment("override.d/10-override.ign", `{
+		"ignition": {"version": "3.4.0"},
+		"storage": {"files": [
+			{"path": "/etc/two", "contents": {"source": "data:,override"}}
+		]}
+	}`)
+	inputs := []Input{
+		{
+			Name: "one.ign",
+			Raw: []byte(`{
+				"ignition": {"version": "3.7.0-experimental"},
+				"storage": {"files": [
+					{"path": "/etc/one", "contents": {"source": "data:,one"}},
+					{"path": "/etc/removed", "deleteInherited": true}
+				]}
+			}`),
+		},
+		{
+			Name: "two.ign",
+			Raw: []byte(`{
+				"ignition": {"version": "3.4.0"},
+				"storage": {"files": [
+					{"path": "/etc/one", "mode": 420},
+					{"path": "/etc/two", "contents": {"source": "data:,two"}}
+				]}
+			}`),
+		},
+	}
+
+	logger := log.New(true)
+	defer logger.Close()
+	cfg, err := Run(inputs, Flags{SystemConfigDir: dir}, &logger)
+	require.NoError(t, err)
+
+	var paths []string
+	for _, f := range cfg.Config.Storage.Files {
+		paths = append(paths, f.Path)
+	}
+	assert.Equal(t, []string{"/etc/base", "/etc/one", "/etc/two"}, paths)
+
+	base := filepath.Join(dir, "base.d", "10-base.ign")
+	override := filepath.Join(dir, "override.d", "10-override.ign")
+	assert.Equal(t, strings.Join([]string{
+		"$.ignition.version ← " + override + ":$.ignition.version",
+		"$.storage.files.0.contents.source ← " + base + ":$.storage.files.0.contents.source",
+		"$.storage.files.0.path ← " + base + ":$.storage.files.0.path",
+		"$.storage.files.1.contents.source ← one.ign:$.storage.files.0.contents.source",
+		"$.storage.files.1.mode ← two.ign:$.storage.files.0.mode",
+		"$.storage.files.1.path ← two.ign:$.storage.files.0.path",
+		"$.storage.files.2.contents.source ← " + override + ":$.storage.files.0.contents.source",
+		"$.storage.files.2.path ← " + override + ":$.storage.files.0.path",
+		"deleted " + base + ":$.storage.files.1 by one.ign:$.storage.files.1",
+	}, "\n"), cfg.String())
+}
@@ -0,0 +1,38 @@
+// Copyright 2026 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package detect
+
+import (
+	"strings"
+)
+
+// implemented in cpuid_amd64.s
+func cpuid(eaxArg, ecxArg uint32) (eax, ebx, ecx, edx uint32)
+
+// hypervisorVendor returns the vendor signature from the hypervisor CPUID
+// leaf, or "" when not running under a hypervisor.
+func hypervisorVendor() string {
+	_, _, ecx, _ := cpuid(1, 0)
+	if ecx&(1<<31) == 0 {
+		return ""
+	}
+
+	_, ebx, ecx, edx := cpuid(0x40000000, 0)
+	var sig []byte
+	for _, reg := range []uint32{ebx, ecx, edx} {
+		sig = append(sig, byte(reg), byte(reg>>8), byte(reg>>16), byte(reg>>24))
+	}
+	return strings.TrimRight(string(sig), "\x00")
+}
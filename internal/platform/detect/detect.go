@@ -0,0 +1,219 @@
+// Copyright 2026 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package detect guesses the platform Ignition is running on, for use when
+// no platform is given on the command line.
+package detect
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/coreos/ignition/v2/internal/distro"
+	"github.com/coreos/ignition/v2/internal/log"
+	"github.com/coreos/ignition/v2/internal/platform"
+)
+
+const (
+	cmdlinePlatformFlag = "ignition.platform.id"
+	fallbackPlatform    = "metal"
+
+	dmiDir      = "/sys/class/dmi/id"
+	fwCfgConfig = "/sys/firmware/qemu_fw_cfg/by_name/opt/com.coreos/config"
+)
+
+// dmiRule matches a DMI field containing a string, case-insensitively.
+type dmiRule struct {
+	field    string
+	value    string
+	platform string
+}
+
+// Rules for platforms which identify themselves in DMI, in order. Azure must
+// come before Hyper-V, since both use Microsoft's vendor string.
+var cloudRules = []dmiRule{
+	{"sys_vendor", "Amazon EC2", "aws"},
+	{"bios_version", "amazon", "aws"},
+	{"product_name", "Google Compute Engine", "gcp"},
+	{"chassis_asset_tag", "7783-7084-3265-9085-8269-3286-77", "azure"},
+	{"chassis_asset_tag", "OracleCloud.com", "oraclecloud"},
+	{"sys_vendor", "Alibaba Cloud", "aliyun"},
+	{"sys_vendor", "DigitalOcean", "digitalocean"},
+	{"sys_vendor", "Exoscale", "exoscale"},
+	{"sys_vendor", "Hetzner", "hetzner"},
+	{"sys_vendor", "Linode", "akamai"},
+	{"sys_vendor", "Akamai", "akamai"},
+	{"sys_vendor", "Scaleway", "scaleway"},
+	{"sys_vendor", "UpCloud", "upcloud"},
+	{"sys_vendor", "Vultr", "vultr"},
+	{"sys_vendor", "Nutanix", "nutanix"},
+	{"product_name", "KubeVirt", "kubevirt"},
+	{"sys_vendor", "KubeVirt", "kubevirt"},
+	{"product_name", "OpenStack", "openstack"},
+	{"product_name", "CloudStack", "cloudstack"},
+	{"sys_vendor", "Microsoft Corporation", "hyperv"},
+	{"product_name", "VirtualBox", "virtualbox"},
+	{"sys_vendor", "VMware", "vmware"},
+}
+
+// Product names which must also be present in DMI for a rule to match, for
+// vendors which sell hardware as well as virtual machines.
+var dmiProducts = map[string]string{
+	"hyperv": "Virtual Machine",
+}
+
+// Rules for generic hypervisors, checked after any seed devices.
+var hypervisorRules = []dmiRule{
+	{"sys_vendor", "QEMU", "qemu"},
+}
+
+// Platforms for the hypervisor vendor reported by CPUID.
+var cpuidVendors = map[string]string{
+	"KVMKVMKVM":    "qemu",
+	"TCGTCGTCGTCG": "qemu",
+	"VMwareVMware": "vmware",
+	"Microsoft Hv": "hyperv",
+}
+
+// Platforms for config drive filesystem labels, in order.
+var deviceLabels = []struct {
+	label    string
+	platform string
+}{
+	{"config-2", "openstack"},
+	{"CONFIG-2", "openstack"},
+	{"cidata", "nocloud"},
+	{"CIDATA", "nocloud"},
+	{"CONTEXT", "opennebula"},
+}
+
+type detector struct {
+	logger *log.Logger
+	// root of the filesystem holding /sys, /dev, and /proc
+	root string
+	// returns the hypervisor vendor reported by CPUID, if any
+	cpuid func() string
+}
+
+// Platform returns the name of the platform Ignition is running on. In order
+// of precedence, the platform is taken from:
+//
+//  1. the ignition.platform.id kernel argument
+//  2. DMI fields identifying a cloud or hypervisor product
+//  3. config drive and NoCloud seed filesystems
+//  4. the QEMU firmware config device, or the QEMU DMI vendor
+//  5. the hypervisor vendor reported by CPUID
+//
+// If nothing matches, the platform is assumed to be bare metal.
+func Platform(logger *log.Logger) string {
+	d := detector{
+		logger: logger,
+		root:   "/",
+		cpuid:  hypervisorVendor,
+	}
+	return d.detect()
+}
+
+func (d detector) detect() string {
+	if name := d.fromCmdline(); name != "" {
+		if _, ok := platform.Get(name); ok {
+			d.logger.Info("using platform %q from kernel command line", name)
+			return name
+		}
+		d.logger.Warning("ignoring unknown platform %q from kernel command line", name)
+	}
+
+	if name := d.fromDMI(cloudRules); name != "" {
+		return name
+	}
+
+	for _, dev := range deviceLabels {
+		path := d.path(filepath.Join(distro.DiskByLabelDir(), dev.label))
+		if exists(path) {
+			d.logger.Info("detected platform %q from filesystem label %q", dev.platform, dev.label)
+			return dev.platform
+		}
+	}
+
+	if exists(d.path(fwCfgConfig)) {
+		d.logger.Info("detected platform %q from QEMU firmware config", "qemu")
+		return "qemu"
+	}
+	if name := d.fromDMI(hypervisorRules); name != "" {
+		return name
+	}
+
+	if vendor := d.cpuid(); vendor != "" {
+		if name, ok := cpuidVendors[vendor]; ok {
+			d.logger.Info("detected platform %q from hypervisor vendor %q", name, vendor)
+			return name
+		}
+		d.logger.Debug("unknown hypervisor vendor %q", vendor)
+	}
+
+	d.logger.Info("couldn't detect platform, assuming %q", fallbackPlatform)
+	return fallbackPlatform
+}
+
+func (d detector) fromCmdline() string {
+	args, err := os.ReadFile(d.path(distro.KernelCmdlinePath()))
+	if err != nil {
+		d.logger.Debug("couldn't read cmdline: %v", err)
+		return ""
+	}
+
+	var name string
+	for _, arg := range strings.Fields(string(args)) {
+		key, value, found := strings.Cut(arg, "=")
+		if key == cmdlinePlatformFlag && found {
+			name = value
+		}
+	}
+	return name
+}
+
+func (d detector) fromDMI(rules []dmiRule) string {
+	for _, rule := range rules {
+		value, err := os.ReadFile(d.path(filepath.Join(dmiDir, rule.field)))
+		if err != nil {
+			continue
+		}
+		if !containsFold(string(value), rule.value) {
+			continue
+		}
+		if want, ok := dmiProducts[rule.platform]; ok {
+			product, err := os.ReadFile(d.path(filepath.Join(dmiDir, "product_name")))
+			if err != nil || !containsFold(string(product), want) {
+				continue
+			}
+		}
+		d.logger.Info("detected platform %q from DMI %s %q", rule.platform, rule.field, strings.TrimSpace(string(value)))
+		return rule.platform
+	}
+	return ""
+}
+
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+func (d detector) path(path string) string {
+	return filepath.Join(d.root, path)
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,159 @@
+// Copyright 2026 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package detect
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/coreos/ignition/v2/internal/log"
+	_ "github.com/coreos/ignition/v2/internal/register"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		files  map[string]string
+		cpuid  string
+		result string
+	}{
+		// nothing to go on
+		{
+			result: "metal",
+		},
+		{
+			files: map[string]string{
+				"sys/class/dmi/id/sys_vendor":   "Amazon EC2\n",
+				"sys/class/dmi/id/product_name": "m5.large\n",
+			},
+			cpuid:  "KVMKVMKVM",
+			result: "aws",
+		},
+		{
+			files: map[string]string{
+				"sys/class/dmi/id/product_name": "Google Compute Engine\n",
+			},
+			result: "gcp",
+		},
+		{
+			files: map[string]string{
+				"sys/class/dmi/id/sys_vendor":        "Microsoft Corporation\n",
+				"sys/class/dmi/id/chassis_asset_tag": "7783-7084-3265-9085-8269-3286-77\n",
+			},
+			cpuid:  "Microsoft Hv",
+			result: "azure",
+		},
+		{
+			files: map[string]string{
+				"sys/class/dmi/id/sys_vendor":   "Microsoft Corporation\n",
+				"sys/class/dmi/id/product_name": "Virtual Machine\n",
+			},
+			cpuid:  "Microsoft Hv",
+			result: "hyperv",
+		},
+		// Microsoft hardware isn't Hyper-V
+		{
+			files: map[string]string{
+				"sys/class/dmi/id/sys_vendor":   "Microsoft Corporation\n",
+				"sys/class/dmi/id/product_name": "Surface Laptop 5\n",
+			},
+			result: "metal",
+		},
+		{
+			files: map[string]string{
+				"sys/class/dmi/id/sys_vendor": "VMware, Inc.\n",
+			},
+			result: "vmware",
+		},
+		// kernel argument overrides detection
+		{
+			files: map[string]string{
+				"proc/cmdline":                "root=/dev/sda ignition.platform.id=vmware quiet\n",
+				"sys/class/dmi/id/sys_vendor": "Amazon EC2\n",
+			},
+			result: "vmware",
+		},
+		// unknown platforms are ignored
+		{
+			files: map[string]string{
+				"proc/cmdline":                "ignition.platform.id=bogus\n",
+				"sys/class/dmi/id/sys_vendor": "Amazon EC2\n",
+			},
+			result: "aws",
+		},
+		// seeds take precedence over generic hypervisors
+		{
+			files: map[string]string{
+				"sys/class/dmi/id/sys_vendor": "QEMU\n",
+				"dev/disk/by-label/cidata":    "",
+			},
+			cpuid:  "KVMKVMKVM",
+			result: "nocloud",
+		},
+		{
+			files: map[string]string{
+				"sys/class/dmi/id/sys_vendor":   "QEMU\n",
+				"sys/class/dmi/id/product_name": "OpenStack Nova\n",
+				"dev/disk/by-label/config-2":    "",
+			},
+			result: "openstack",
+		},
+		{
+			files: map[string]string{
+				"sys/class/dmi/id/sys_vendor": "QEMU\n",
+				"dev/disk/by-label/CONTEXT":   "",
+			},
+			result: "opennebula",
+		},
+		{
+			files: map[string]string{
+				"sys/class/dmi/id/sys_vendor": "QEMU\n",
+			},
+			result: "qemu",
+		},
+		{
+			files: map[string]string{
+				"sys/firmware/qemu_fw_cfg/by_name/opt/com.coreos/config/raw": "{}",
+			},
+			result: "qemu",
+		},
+		{
+			cpuid:  "KVMKVMKVM",
+			result: "qemu",
+		},
+		{
+			cpuid:  "bhyve bhyve ",
+			result: "metal",
+		},
+	}
+
+	logger := log.New(true)
+	for i, test := range tests {
+		root := t.TempDir()
+		for path, contents := range test.files {
+			path = filepath.Join(root, path)
+			assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+			assert.NoError(t, os.WriteFile(path, []byte(contents), 0644))
+		}
+		d := detector{
+			logger: &logger,
+			root:   root,
+			cpuid:  func() string { return test.cpuid },
+		}
+		assert.Equal(t, test.result, d.detect(), "#%d: bad platform", i)
+	}
+}
@@ -25,8 +25,9 @@ func (s Name) String() string {
 	return string(s)
 }
 
+// Set accepts an empty value, which leaves the platform to be detected.
 func (s *Name) Set(val string) error {
-	if _, ok := Get(val); !ok {
+	if _, ok := Get(val); !ok && val != "" {
 		return fmt.Errorf("%s is not a valid platform", val)
 	}
 
@@ -49,6 +49,10 @@ type Provider struct {
 	Status     func(stageName string, f resource.Fetcher, e error) error
 	DelConfig  func(f *resource.Fetcher) error
 
+	// Fetch the SSH public keys provided by the platform's metadata
+	// service, if the platform has one.
+	FetchSSHKeys func(f *resource.Fetcher) ([]string, error)
+
 	// Fetch, and also save output files to be written during files stage.
 	// Avoid, unless you're certain you need it.
 	FetchWithFiles func(f *resource.Fetcher) ([]types.File, types.Config, report.Report, error)
@@ -96,6 +100,15 @@ func (c Config) Status(stageName string, f resource.Fetcher, statusErr error) er
 	return nil
 }
 
+// FetchSSHKeys returns the platform's SSH public keys. Platforms which don't
+// provide any return none.
+func (c Config) FetchSSHKeys(f *resource.Fetcher) ([]string, error) {
+	if c.p.FetchSSHKeys != nil {
+		return c.p.FetchSSHKeys(f)
+	}
+	return nil, nil
+}
+
 func (c Config) DelConfig(f *resource.Fetcher) error {
 	if c.p.DelConfig != nil {
 		return c.p.DelConfig(f)
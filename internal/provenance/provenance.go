@@ -0,0 +1,226 @@
+// Copyright 2026 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The provenance package tracks which input config each field of a merged
+// config came from, using the transcripts of config/merge.
+package provenance
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/coreos/ignition/v2/config/merge"
+	latest "github.com/coreos/ignition/v2/config/v3_7_experimental"
+	"github.com/coreos/ignition/v2/config/v3_7_experimental/types"
+
+	"github.com/coreos/vcontext/path"
+)
+
+// Origin is where a field of a config came from.
+type Origin struct {
+	// Source names the input config, such as its path or URL.
+	Source string
+	// Path is the path of the field in the input config.
+	Path path.ContextPath
+}
+
+func (o Origin) String() string {
+	return fmt.Sprintf("%s:%s", o.Source, o.Path)
+}
+
+// Field is a populated field of a config, along with its origins. Fields
+// merged from several input configs have several origins.
+type Field struct {
+	Path    path.ContextPath
+	Origins []Origin
+}
+
+// Deletion is an entry inherited from a parent config, which an entry of a
+// child config deleted during merging.
+type Deletion struct {
+	Deleted []Origin
+	By      []Origin
+}
+
+// Config is a config along with the origins of its fields. The zero
+// value, like configs from Untracked, doesn't track origins.
+type Config struct {
+	Config types.Config
+	// whether the config tracks the origins of its fields
+	tracked bool
+	// fields by the string form of their path
+	fields    map[string]Field
+	deletions []Deletion
+}
+
+// New returns the config from the named source, with every field
+// originating from it.
+func New(source string, cfg types.Config) Config {
+	// merging into an empty config transcribes every populated field
+	_, transcript := merge.MergeStructTranscribe(types.Config{}, cfg)
+	fields := map[string]Field{}
+	for _, m := range transcript.Mappings {
+		p := path.New("", m.From.Path...)
+		fields[p.String()] = Field{
+			Path: p,
+			Origins: []Origin{{
+				Source: source,
+				Path:   p,
+			}},
+		}
+	}
+	return Config{
+		Config:  cfg,
+		tracked: true,
+		fields:  fields,
+	}
+}
+
+// Untracked returns the config without tracking the origins of its fields.
+// Merging untracked configs is plain v3_7_experimental.Merge, so callers
+// can share code with and without tracking.
+func Untracked(cfg types.Config) Config {
+	return Config{Config: cfg}
+}
+
+// Merge merges the child config into the parent config as
+// v3_7_experimental.Merge does, tracking the origins of the fields.
+func Merge(parent, child Config) Config {
+	if !parent.tracked && !child.tracked {
+		return Untracked(latest.Merge(parent.Config, child.Config))
+	}
+	result, transcript := merge.MergeStructTranscribe(parent.Config, child.Config)
+	fields := map[string]Field{}
+	from := func(p path.ContextPath) Field {
+		if p.Tag == merge.TAG_PARENT {
+			return parent.fields[p.String()]
+		}
+		return child.fields[p.String()]
+	}
+	for _, m := range transcript.Mappings {
+		key := m.To.String()
+		field, ok := fields[key]
+		if !ok {
+			field.Path = path.New("", m.To.Path...)
+		}
+		field.Origins = append(field.Origins, from(m.From).Origins...)
+		fields[key] = field
+	}
+
+	deletions := append(append([]Deletion{}, parent.deletions...), child.deletions...)
+	for _, d := range transcript.Deletions {
+		deletions = append(deletions, Deletion{
+			Deleted: from(d.Parent).Origins,
+			By:      from(d.Child).Origins,
+		})
+	}
+
+	return Config{
+		Config:    result.(types.Config),
+		tracked:   true,
+		fields:    fields,
+		deletions: deletions,
+	}
+}
+
+// StripDeletions removes the entries which delete inherited entries from
+// the config as v3_7_experimental.StripDeletions does, tracking the origins
+// of the remaining fields.
+func StripDeletions(c Config) Config {
+	if !c.tracked {
+		return Untracked(latest.StripDeletions(c.Config))
+	}
+	result, transcript := merge.StripDeletionsTranscribe(c.Config)
+	fields := map[string]Field{}
+	for _, m := range transcript.Mappings {
+		field := c.fields[m.From.String()]
+		field.Path = path.New("", m.To.Path...)
+		fields[field.Path.String()] = field
+	}
+	return Config{
+		Config:    result.(types.Config),
+		tracked:   true,
+		fields:    fields,
+		deletions: c.deletions,
+	}
+}
+
+// Fields returns the populated leaf fields of the config with their
+// origins, ordered by path.
+func (c Config) Fields() []Field {
+	interior := map[string]struct{}{}
+	for _, field := range c.fields {
+		for i := 0; i < len(field.Path.Path); i++ {
+			interior[path.New("", field.Path.Path[:i]...).String()] = struct{}{}
+		}
+	}
+	var fields []Field
+	for key, field := range c.fields {
+		if _, ok := interior[key]; !ok {
+			fields = append(fields, field)
+		}
+	}
+	sort.Slice(fields, func(i, j int) bool {
+		return lessPath(fields[i].Path, fields[j].Path)
+	})
+	return fields
+}
+
+// Deletions returns the entries deleted while merging the config, in the
+// order they were deleted.
+func (c Config) Deletions() []Deletion {
+	return c.deletions
+}
+
+// String describes the origin of every field and every deletion, one per
+// line.
+func (c Config) String() string {
+	var lines []string
+	for _, field := range c.Fields() {
+		lines = append(lines, fmt.Sprintf("%s ← %s", field.Path, joinOrigins(field.Origins)))
+	}
+	for _, d := range c.deletions {
+		lines = append(lines, fmt.Sprintf("deleted %s by %s", joinOrigins(d.Deleted), joinOrigins(d.By)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func joinOrigins(origins []Origin) string {
+	var strs []string
+	for _, o := range origins {
+		strs = append(strs, o.String())
+	}
+	return strings.Join(strs, ", ")
+}
+
+// lessPath orders paths element by element, list indexes numerically.
+func lessPath(a, b path.ContextPath) bool {
+	for i := 0; i < len(a.Path) && i < len(b.Path); i++ {
+		ai, aIsInt := a.Path[i].(int)
+		bi, bIsInt := b.Path[i].(int)
+		switch {
+		case aIsInt && bIsInt:
+			if ai != bi {
+				return ai < bi
+			}
+		default:
+			as, bs := fmt.Sprint(a.Path[i]), fmt.Sprint(b.Path[i])
+			if as != bs {
+				return as < bs
+			}
+		}
+	}
+	return len(a.Path) < len(b.Path)
+}
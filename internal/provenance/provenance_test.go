@@ -0,0 +1,146 @@
+// Copyright 2026 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provenance
+
+import (
+	"testing"
+
+	"github.com/coreos/ignition/v2/config/util"
+	latest "github.com/coreos/ignition/v2/config/v3_7_experimental"
+	"github.com/coreos/ignition/v2/config/v3_7_experimental/types"
+
+	"github.com/coreos/vcontext/path"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMerge(t *testing.T) {
+	base := New("base.ign", types.Config{
+		Ignition: types.Ignition{Version: "3.7.0-experimental"},
+		Storage: types.Storage{
+			Files: []types.File{
+				{
+					Node: types.Node{Path: "/etc/a"},
+					FileEmbedded1: types.FileEmbedded1{
+						Contents: types.Resource{Source: util.StrToPtr("data:,base")},
+					},
+				},
+				{
+					Node: types.Node{Path: "/etc/b"},
+				},
+			},
+		},
+	})
+	user := New("user.ign", types.Config{
+		Ignition: types.Ignition{Version: "3.7.0-experimental"},
+		Storage: types.Storage{
+			Files: []types.File{
+				{
+					Node: types.Node{Path: "/etc/a"},
+					FileEmbedded1: types.FileEmbedded1{
+						Mode: util.IntToPtr(0644),
+					},
+				},
+				{
+					Node: types.Node{Path: "/etc/b", DeleteInherited: util.BoolToPtr(true)},
+				},
+			},
+		},
+	})
+
+	merged := Merge(base, user)
+	assert.Equal(t, []Field{
+		{
+			Path:    path.New("", "ignition", "version"),
+			Origins: []Origin{{Source: "user.ign", Path: path.New("", "ignition", "version")}},
+		},
+		{
+			Path:    path.New("", "storage", "files", 0, "contents", "source"),
+			Origins: []Origin{{Source: "base.ign", Path: path.New("", "storage", "files", 0, "contents", "source")}},
+		},
+		{
+			Path:    path.New("", "storage", "files", 0, "mode"),
+			Origins: []Origin{{Source: "user.ign", Path: path.New("", "storage", "files", 0, "mode")}},
+		},
+		{
+			Path:    path.New("", "storage", "files", 0, "path"),
+			Origins: []Origin{{Source: "user.ign", Path: path.New("", "storage", "files", 0, "path")}},
+		},
+		{
+			Path:    path.New("", "storage", "files", 1, "deleteInherited"),
+			Origins: []Origin{{Source: "user.ign", Path: path.New("", "storage", "files", 1, "deleteInherited")}},
+		},
+		{
+			Path:    path.New("", "storage", "files", 1, "path"),
+			Origins: []Origin{{Source: "user.ign", Path: path.New("", "storage", "files", 1, "path")}},
+		},
+	}, merged.Fields())
+	assert.Equal(t, []Deletion{
+		{
+			Deleted: []Origin{{Source: "base.ign", Path: path.New("", "storage", "files", 1)}},
+			By:      []Origin{{Source: "user.ign", Path: path.New("", "storage", "files", 1)}},
+		},
+	}, merged.Deletions())
+	assert.Equal(t, `$.ignition.version ← user.ign:$.ignition.version
+$.storage.files.0.contents.source ← base.ign:$.storage.files.0.contents.source
+$.storage.files.0.mode ← user.ign:$.storage.files.0.mode
+$.storage.files.0.path ← user.ign:$.storage.files.0.path
+$.storage.files.1.deleteInherited ← user.ign:$.storage.files.1.deleteInherited
+$.storage.files.1.path ← user.ign:$.storage.files.1.path
+deleted base.ign:$.storage.files.1 by user.ign:$.storage.files.1`, merged.String())
+
+	// origins are kept through further merges
+	merged = Merge(New("empty.ign", types.Config{}), merged)
+	assert.Equal(t, []Origin{{Source: "base.ign", Path: path.New("", "storage", "files", 0, "contents", "source")}}, merged.Fields()[1].Origins)
+	assert.Len(t, merged.Deletions(), 1)
+}
+
+func TestFieldsOrder(t *testing.T) {
+	var users []types.PasswdUser
+	for _, name := range []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k"} {
+		users = append(users, types.PasswdUser{Name: name})
+	}
+	cfg := New("config.ign", types.Config{
+		Passwd: types.Passwd{Users: users},
+	})
+
+	var names []interface{}
+	for _, field := range cfg.Fields() {
+		names = append(names, field.Path.Path[2])
+	}
+	// indexes are ordered numerically
+	assert.Equal(t, []interface{}{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, names)
+}
+
+func TestUntracked(t *testing.T) {
+	parent := types.Config{
+		Passwd: types.Passwd{Users: []types.PasswdUser{{Name: "core"}}},
+	}
+	child := types.Config{
+		Passwd: types.Passwd{Users: []types.PasswdUser{
+			{Name: "core", DeleteInherited: util.BoolToPtr(true)},
+			{Name: "admin"},
+		}},
+	}
+
+	merged := StripDeletions(Merge(Untracked(parent), Untracked(child)))
+	assert.Equal(t, latest.StripDeletions(latest.Merge(parent, child)), merged.Config)
+	assert.Empty(t, merged.Fields())
+	assert.Empty(t, merged.Deletions())
+
+	// merging a tracked config tracks the result
+	merged = Merge(Untracked(parent), New("child.ign", child))
+	assert.NotEmpty(t, merged.Fields())
+	assert.Len(t, merged.Deletions(), 1)
+}
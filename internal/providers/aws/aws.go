@@ -22,6 +22,7 @@ import (
 	"errors"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 
 	"github.com/coreos/ignition/v2/config/v3_7_experimental/types"
@@ -42,6 +43,11 @@ var (
 		Host:   "169.254.169.254",
 		Path:   "2019-10-01/user-data",
 	}
+	publicKeysURL = url.URL{
+		Scheme: "http",
+		Host:   "169.254.169.254",
+		Path:   "2019-10-01/meta-data/public-keys/",
+	}
 	imdsTokenURL = url.URL{
 		Scheme: "http",
 		Host:   "169.254.169.254",
@@ -52,10 +58,11 @@ var (
 
 func init() {
 	platform.Register(platform.Provider{
-		Name:       "aws",
-		NewFetcher: newFetcher,
-		Fetch:      fetchConfig,
-		Init:       doInit,
+		Name:         "aws",
+		NewFetcher:   newFetcher,
+		Fetch:        fetchConfig,
+		FetchSSHKeys: fetchSSHKeys,
+		Init:         doInit,
 	})
 }
 
@@ -68,6 +75,30 @@ func fetchConfig(f *resource.Fetcher) (types.Config, report.Report, error) {
 	return util.ParseConfig(f.Logger, data)
 }
 
+// fetchSSHKeys returns the instance's key pairs. IMDS lists them as
+// "<index>=<name>" and serves each key under its index.
+func fetchSSHKeys(f *resource.Fetcher) ([]string, error) {
+	data, err := fetchFromAWSMetadata(publicKeysURL, resource.FetchOptions{}, f)
+	if err == resource.ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	for _, entry := range util.ParseSSHKeys(data) {
+		index, _, _ := strings.Cut(entry, "=")
+		u := publicKeysURL
+		u.Path += index + "/openssh-key"
+		key, err := fetchFromAWSMetadata(u, resource.FetchOptions{}, f)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, util.ParseSSHKeys(key)...)
+	}
+	return keys, nil
+}
+
 func newFetcher(l *log.Logger) (resource.Fetcher, error) {
 	cfg := aws.Config{Credentials: aws.NewCredentialsCache(ec2rolecreds.New())}
 	return resource.Fetcher{
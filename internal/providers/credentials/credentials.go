@@ -0,0 +1,97 @@
+// Copyright 2026 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The credentials provider fetches a configuration from the systemd
+// credential "ignition.config", or a remote configuration from the URL in
+// the credential "ignition.config.url". Credentials can be passed to the
+// initrd by systemd-nspawn, systemd-vmspawn, or SMBIOS OEM strings.
+
+package credentials
+
+import (
+	"errors"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/coreos/ignition/v2/config/v3_7_experimental/types"
+	"github.com/coreos/ignition/v2/internal/distro"
+	"github.com/coreos/ignition/v2/internal/platform"
+	"github.com/coreos/ignition/v2/internal/providers/util"
+	"github.com/coreos/ignition/v2/internal/resource"
+
+	"github.com/coreos/vcontext/report"
+)
+
+const (
+	configCredential    = "ignition.config"
+	configURLCredential = "ignition.config.url"
+)
+
+var (
+	// we are a special-cased system provider; don't register ourselves
+	// for lookup by name
+	Config = platform.NewConfig(platform.Provider{
+		Name:  "credentials",
+		Fetch: fetchConfig,
+	})
+)
+
+func fetchConfig(f *resource.Fetcher) (types.Config, report.Report, error) {
+	data, err := fetchConfigFromDirs(f, distro.CredentialDirs())
+	if err != nil {
+		return types.Config{}, report.Report{}, err
+	}
+	return util.ParseConfig(f.Logger, data)
+}
+
+// fetchConfigFromDirs returns the config from the first of dirs holding
+// either credential, fetching it if only a URL is given. It returns
+// platform.ErrNoProvider if no credential is found.
+func fetchConfigFromDirs(f *resource.Fetcher, dirs []string) ([]byte, error) {
+	for _, dir := range dirs {
+		data, err := readCredential(dir, configCredential)
+		if err == nil {
+			f.Logger.Info("reading config from credential %q in %q", configCredential, dir)
+			return data, nil
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			f.Logger.Err("couldn't read credential %q: %v", configCredential, err)
+			return nil, err
+		}
+
+		rawURL, err := readCredential(dir, configURLCredential)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			f.Logger.Err("couldn't read credential %q: %v", configURLCredential, err)
+			return nil, err
+		}
+		u, err := url.Parse(strings.TrimSpace(string(rawURL)))
+		if err != nil {
+			f.Logger.Err("failed to parse url from credential %q: %v", configURLCredential, err)
+			return nil, err
+		}
+		f.Logger.Info("fetching config from credential %q in %q", configURLCredential, dir)
+		return f.FetchToBuffer(*u, resource.FetchOptions{})
+	}
+
+	f.Logger.Info("no config credentials found")
+	return nil, platform.ErrNoProvider
+}
+
+func readCredential(dir, name string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(dir, name))
+}
@@ -0,0 +1,88 @@
+// Copyright 2026 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package credentials
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/coreos/ignition/v2/internal/log"
+	"github.com/coreos/ignition/v2/internal/platform"
+	"github.com/coreos/ignition/v2/internal/resource"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFetchConfigFromDirs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("remote"))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		creds []map[string]string
+		out   string
+		err   error
+	}{
+		{
+			creds: []map[string]string{{}, {}},
+			err:   platform.ErrNoProvider,
+		},
+		{
+			creds: []map[string]string{{}, {"ignition.config": "system"}},
+			out:   "system",
+		},
+		// the service's credentials come first
+		{
+			creds: []map[string]string{{"ignition.config": "service"}, {"ignition.config": "system"}},
+			out:   "service",
+		},
+		{
+			creds: []map[string]string{{"ignition.config.url": server.URL + "\n"}, {"ignition.config": "system"}},
+			out:   "remote",
+		},
+		// inline configs take precedence over URLs
+		{
+			creds: []map[string]string{{"ignition.config.url": server.URL, "ignition.config": "inline"}},
+			out:   "inline",
+		},
+		{
+			creds: []map[string]string{{"other": "value"}},
+			err:   platform.ErrNoProvider,
+		},
+	}
+
+	logger := log.New(true)
+	f := resource.Fetcher{Logger: &logger}
+	for i, test := range tests {
+		var dirs []string
+		for _, creds := range test.creds {
+			dir := t.TempDir()
+			for name, value := range creds {
+				assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(value), 0400))
+			}
+			dirs = append(dirs, dir)
+		}
+		// missing directories are skipped
+		dirs = append([]string{filepath.Join(t.TempDir(), "missing")}, dirs...)
+
+		data, err := fetchConfigFromDirs(&f, dirs)
+		assert.Equal(t, test.err, err, "#%d: bad error", i)
+		assert.Equal(t, test.out, string(data), "#%d: bad config", i)
+	}
+}
@@ -34,12 +34,18 @@ var (
 		Host:   "169.254.169.254",
 		Path:   "metadata/v1/user-data",
 	}
+	publicKeysUrl = url.URL{
+		Scheme: "http",
+		Host:   "169.254.169.254",
+		Path:   "metadata/v1/public-keys",
+	}
 )
 
 func init() {
 	platform.Register(platform.Provider{
-		Name:  "digitalocean",
-		Fetch: fetchConfig,
+		Name:         "digitalocean",
+		Fetch:        fetchConfig,
+		FetchSSHKeys: fetchSSHKeys,
 	})
 }
 
@@ -51,3 +57,12 @@ func fetchConfig(f *resource.Fetcher) (types.Config, report.Report, error) {
 
 	return util.ParseConfig(f.Logger, data)
 }
+
+func fetchSSHKeys(f *resource.Fetcher) ([]string, error) {
+	data, err := f.FetchToBuffer(publicKeysUrl, resource.FetchOptions{})
+	if err != nil && err != resource.ErrNotFound {
+		return nil, err
+	}
+
+	return util.ParseSSHKeys(data), nil
+}
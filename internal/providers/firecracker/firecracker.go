@@ -0,0 +1,134 @@
+// Copyright 2026 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The firecracker provider fetches a configuration from the user-data key of
+// the Firecracker microVM metadata service (MMDS).
+
+package firecracker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/coreos/ignition/v2/config/v3_7_experimental/types"
+	"github.com/coreos/ignition/v2/internal/platform"
+	"github.com/coreos/ignition/v2/internal/providers/util"
+	"github.com/coreos/ignition/v2/internal/resource"
+
+	"github.com/coreos/vcontext/report"
+)
+
+const (
+	userdataPath = "latest/user-data"
+	tokenPath    = "latest/api/token"
+	tokenTTL     = "21600"
+)
+
+var (
+	mmdsURL = url.URL{
+		Scheme: "http",
+		Host:   "169.254.169.254",
+	}
+	errMMDSV2 = errors.New("failed to fetch MMDS V2 session token")
+)
+
+func init() {
+	platform.Register(platform.Provider{
+		Name:  "firecracker",
+		Fetch: fetchConfig,
+	})
+}
+
+func fetchConfig(f *resource.Fetcher) (types.Config, report.Report, error) {
+	data, err := fetchFromMMDS(f, mmdsURL)
+	if err != nil {
+		return types.Config{}, report.Report{}, err
+	}
+
+	return util.ParseConfig(f.Logger, data)
+}
+
+// fetchFromMMDS returns the user-data in the MMDS at base. It returns nil if
+// there is no user-data.
+func fetchFromMMDS(f *resource.Fetcher, base url.URL) ([]byte, error) {
+	// MMDS rejects the Accept header sent for configs, and without an
+	// Accept header it returns objects as a list of keys. Ask for JSON,
+	// so user-data can be stored either as a string or as the config
+	// object itself.
+	opts := resource.FetchOptions{
+		Headers: http.Header{
+			"Accept": []string{"application/json"},
+		},
+	}
+
+	token, err := fetchMMDSToken(f, base)
+	if err == errMMDSV2 {
+		f.Logger.Info("MMDS V2 session token is unavailable; falling back to MMDS V1")
+	} else if err != nil {
+		return nil, err
+	} else {
+		opts.Headers.Set("X-metadata-token", token)
+	}
+
+	data, err := f.FetchToBuffer(*base.JoinPath(userdataPath), opts)
+	if err == resource.ErrNotFound {
+		f.Logger.Info("MMDS has no user-data")
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return decodeUserdata(data)
+}
+
+// fetchMMDSToken fetches a session token, which MMDS V2 requires and MMDS V1
+// may not support.
+func fetchMMDSToken(f *resource.Fetcher, base url.URL) (string, error) {
+	opts := resource.FetchOptions{
+		Headers: http.Header{
+			"X-metadata-token-ttl-seconds": []string{tokenTTL},
+		},
+		HTTPVerb: "PUT",
+	}
+	u := base.JoinPath(tokenPath)
+	token, err := f.FetchToBuffer(*u, opts)
+	if err == resource.ErrNotFound {
+		f.Logger.Debug("cannot read MMDS session token from %q", u.String())
+		return "", errMMDSV2
+	} else if err != nil {
+		f.Logger.Debug("unexpected error retrieving MMDS session token: %v", err)
+		return "", err
+	}
+	return string(token), nil
+}
+
+// decodeUserdata returns a config from user-data returned as JSON, which is
+// either a string holding the config or the config object.
+func decodeUserdata(data []byte) ([]byte, error) {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, fmt.Errorf("decoding MMDS user-data: %w", err)
+	}
+	switch v := value.(type) {
+	case string:
+		return []byte(v), nil
+	case map[string]any:
+		return data, nil
+	default:
+		return nil, fmt.Errorf("MMDS user-data must be a string or an object, not %T", value)
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2026 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firecracker
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/coreos/ignition/v2/internal/log"
+	"github.com/coreos/ignition/v2/internal/resource"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// mmdsServer is a stand-in for the Firecracker MMDS.
+type mmdsServer struct {
+	// MMDS V2 requires session tokens; V1 doesn't support them
+	v2       bool
+	userdata string
+}
+
+func (s mmdsServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/latest/api/token":
+		if !s.v2 {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Method != http.MethodPut || r.Header.Get("X-metadata-token-ttl-seconds") == "" {
+			http.Error(w, "bad token request", http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte("token"))
+	case "/latest/user-data":
+		if s.v2 && r.Header.Get("X-metadata-token") != "token" {
+			http.Error(w, "missing token", http.StatusUnauthorized)
+			return
+		}
+		if r.Header.Get("Accept") != "application/json" {
+			http.Error(w, "unsupported Accept header", http.StatusBadRequest)
+			return
+		}
+		if s.userdata == "" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(s.userdata))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestFetchFromMMDS(t *testing.T) {
+	tests := []struct {
+		server mmdsServer
+		out    string
+		err    bool
+	}{
+		{
+			server: mmdsServer{v2: true, userdata: `"{\"ignition\": {\"version\": \"3.0.0\"}}"`},
+			out:    `{"ignition": {"version": "3.0.0"}}`,
+		},
+		{
+			server: mmdsServer{v2: true, userdata: `{"ignition": {"version": "3.0.0"}}`},
+			out:    `{"ignition": {"version": "3.0.0"}}`,
+		},
+		{
+			server: mmdsServer{userdata: `"{}"`},
+			out:    `{}`,
+		},
+		// no user-data
+		{
+			server: mmdsServer{v2: true},
+		},
+		{
+			server: mmdsServer{v2: true, userdata: `["ignition"]`},
+			err:    true,
+		},
+	}
+
+	logger := log.New(true)
+	for i, test := range tests {
+		server := httptest.NewServer(test.server)
+		u, err := url.Parse(server.URL)
+		assert.NoError(t, err)
+		f := resource.Fetcher{Logger: &logger}
+
+		data, err := fetchFromMMDS(&f, *u)
+		server.Close()
+		if test.err {
+			assert.Error(t, err, "#%d: expected error", i)
+			continue
+		}
+		assert.NoError(t, err, "#%d: unexpected error", i)
+		assert.Equal(t, test.out, string(data), "#%d: bad config", i)
+	}
+}
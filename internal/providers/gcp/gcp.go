@@ -20,6 +20,7 @@ package gcp
 import (
 	"net/http"
 	"net/url"
+	"strings"
 
 	"github.com/coreos/ignition/v2/config/v3_7_experimental/types"
 	"github.com/coreos/ignition/v2/internal/platform"
@@ -35,26 +36,91 @@ var (
 		Host:   "169.254.169.254",
 		Path:   "computeMetadata/v1/instance/attributes/user-data",
 	}
+	instanceSSHKeysUrl = url.URL{
+		Scheme: "http",
+		Host:   "169.254.169.254",
+		Path:   "computeMetadata/v1/instance/attributes/ssh-keys",
+	}
+	blockProjectSSHKeysUrl = url.URL{
+		Scheme: "http",
+		Host:   "169.254.169.254",
+		Path:   "computeMetadata/v1/instance/attributes/block-project-ssh-keys",
+	}
+	projectSSHKeysUrl = url.URL{
+		Scheme: "http",
+		Host:   "169.254.169.254",
+		Path:   "computeMetadata/v1/project/attributes/ssh-keys",
+	}
 	metadataHeaderKey = "Metadata-Flavor"
 	metadataHeaderVal = "Google"
 )
 
 func init() {
 	platform.Register(platform.Provider{
-		Name:  "gcp",
-		Fetch: fetchConfig,
+		Name:         "gcp",
+		Fetch:        fetchConfig,
+		FetchSSHKeys: fetchSSHKeys,
 	})
 }
 
 func fetchConfig(f *resource.Fetcher) (types.Config, report.Report, error) {
+	data, err := fetchMetadata(f, userdataUrl)
+	if err != nil {
+		return types.Config{}, report.Report{}, err
+	}
+
+	return util.ParseConfig(f.Logger, data)
+}
+
+// fetchSSHKeys returns the instance's SSH keys, followed by the project's
+// unless the instance blocks them.
+func fetchSSHKeys(f *resource.Fetcher) ([]string, error) {
+	data, err := fetchMetadata(f, instanceSSHKeysUrl)
+	if err != nil {
+		return nil, err
+	}
+	keys := parseSSHKeys(data)
+
+	block, err := fetchMetadata(f, blockProjectSSHKeysUrl)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(string(block)) == "true" {
+		return keys, nil
+	}
+
+	data, err = fetchMetadata(f, projectSSHKeysUrl)
+	if err != nil {
+		return nil, err
+	}
+	return append(keys, parseSSHKeys(data)...), nil
+}
+
+// fetchMetadata fetches a metadata value, treating a missing value as empty.
+func fetchMetadata(f *resource.Fetcher, u url.URL) ([]byte, error) {
 	headers := make(http.Header)
 	headers.Set(metadataHeaderKey, metadataHeaderVal)
-	data, err := f.FetchToBuffer(userdataUrl, resource.FetchOptions{
+	data, err := f.FetchToBuffer(u, resource.FetchOptions{
 		Headers: headers,
 	})
 	if err != nil && err != resource.ErrNotFound {
-		return types.Config{}, report.Report{}, err
+		return nil, err
 	}
+	return data, nil
+}
 
-	return util.ParseConfig(f.Logger, data)
+// parseSSHKeys parses GCE's "username:key" format, dropping the username
+// since the keys are authorized for the user chosen by the config.
+func parseSSHKeys(data []byte) []string {
+	var keys []string
+	for _, line := range util.ParseSSHKeys(data) {
+		_, key, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
 }
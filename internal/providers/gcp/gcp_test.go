@@ -0,0 +1,50 @@
+// Copyright 2026 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSSHKeys(t *testing.T) {
+	tests := []struct {
+		in  string
+		out []string
+	}{
+		{
+			in:  "",
+			out: nil,
+		},
+		{
+			in:  "core:ssh-ed25519 AAAA core@example\n",
+			out: []string{"ssh-ed25519 AAAA core@example"},
+		},
+		{
+			in:  "alice:ssh-rsa BBBB\n\nbob:ecdsa-sha2-nistp256 CCCC bob@example\n",
+			out: []string{"ssh-rsa BBBB", "ecdsa-sha2-nistp256 CCCC bob@example"},
+		},
+		{
+			// entries without a username aren't in GCE's format
+			in:  "ssh-rsa DDDD\nalice:\n",
+			out: nil,
+		},
+	}
+
+	for i, test := range tests {
+		assert.Equal(t, test.out, parseSSHKeys([]byte(test.in)), "#%d", i)
+	}
+}
@@ -18,6 +18,8 @@
 package hetzner
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/url"
 
 	"github.com/coreos/ignition/v2/config/v3_7_experimental/types"
@@ -34,12 +36,18 @@ var (
 		Host:   "169.254.169.254",
 		Path:   "hetzner/v1/userdata",
 	}
+	publicKeysURL = url.URL{
+		Scheme: "http",
+		Host:   "169.254.169.254",
+		Path:   "hetzner/v1/metadata/public-keys",
+	}
 )
 
 func init() {
 	platform.Register(platform.Provider{
-		Name:  "hetzner",
-		Fetch: fetchConfig,
+		Name:         "hetzner",
+		Fetch:        fetchConfig,
+		FetchSSHKeys: fetchSSHKeys,
 	})
 }
 
@@ -52,3 +60,20 @@ func fetchConfig(f *resource.Fetcher) (types.Config, report.Report, error) {
 
 	return util.ParseConfig(f.Logger, data)
 }
+
+func fetchSSHKeys(f *resource.Fetcher) ([]string, error) {
+	data, err := f.FetchToBuffer(publicKeysURL, resource.FetchOptions{})
+	if err != nil && err != resource.ErrNotFound {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	// the metadata service returns a JSON list of keys
+	var keys []string
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("parsing public keys: %w", err)
+	}
+	return keys, nil
+}
@@ -40,6 +40,7 @@ func init() {
 	platform.Register(platform.Provider{
 		Name:           "hyperv",
 		FetchWithFiles: fetchConfig,
+		Status:         reportStatus,
 	})
 }
 
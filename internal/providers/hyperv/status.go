@@ -0,0 +1,322 @@
+// Copyright 2026 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hyperv
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+	"unicode/utf8"
+
+	"github.com/containers/libhvee/pkg/kvp"
+	"github.com/coreos/ignition/v2/internal/log"
+	"github.com/coreos/ignition/v2/internal/resource"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// guestPoolID is the pool the guest publishes to the host
+	// (KVP_POOL_GUEST in hv_kvp_daemon).
+	guestPoolID kvp.PoolID = 1
+
+	// statusKey holds the outcome of the latest stage, and
+	// statusKeyPrefix plus the stage name holds the outcome of each.
+	statusKey       = "ignition.status"
+	statusKeyPrefix = "ignition.status."
+
+	// finalStage is the last stage Ignition runs
+	finalStage = "umount"
+
+	poolRecordSize = kvp.HvKvpExchangeMaxKeySize + kvp.HvKvpExchangeMaxValueSize
+
+	// Host requests over the kernel device are a struct hv_kvp_msg from
+	// linux/hyperv.h, whose layout is checked by TestMessageLayout:
+	//
+	//	struct hv_kvp_msg {
+	//		union {
+	//			struct hv_kvp_hdr kvp_hdr; // __u8 operation, pool; __u16 pad
+	//			int error;                 // result of the reply
+	//		};
+	//		union {
+	//			struct hv_kvp_msg_get kvp_get;             // value at 4
+	//			struct hv_kvp_msg_enumerate kvp_enum_data; // __u32 index at 4, value at 8
+	//			struct hv_kvp_ipaddr_value kvp_ip_val;     // largest member, 7426 bytes
+	//			...
+	//		} body;
+	//	};
+	//
+	// Values are a packed struct hv_kvp_exchg_msg_value of three __u32s
+	// (value_type, key_size, value_size), then the key and value
+	// buffers. The whole message is 7432 bytes, as in libhvee.
+	msgSize          = 7432
+	msgResultSize    = 4
+	valueKeyOffset   = 12
+	valueValueOffset = valueKeyOffset + kvp.HvKvpExchangeMaxKeySize
+	valueSize        = valueValueOffset + kvp.HvKvpExchangeMaxValueSize
+	getValueOffset   = msgResultSize
+	enumIndexOffset  = msgResultSize
+	enumValueOffset  = enumIndexOffset + 4
+	minHostMsgSize   = enumValueOffset + valueSize
+
+	// KVP_OP_GET and KVP_OP_ENUMERATE, and HV_S_CONT, which answers
+	// requests for missing records
+	opGet       = 0
+	opEnumerate = 3
+	hvSCont     = 0x80070103
+)
+
+var (
+	guestPoolPath = filepath.Join(kvp.DefaultKVPFilePath, fmt.Sprintf("%s%d", kvp.DefaultKVPBaseName, guestPoolID))
+	devicePath    = kvp.KernelDevice
+
+	// how long to keep answering the host after its last request, in ms
+	serveTimeout = 5 * kvp.Timeout
+	// how long to answer the host at most, however often it asks
+	maxServeTime = 30 * time.Second
+)
+
+// reportStatus records the outcome of the stage in the guest pool. If
+// hv_kvp_daemon is running, it serves the pool file to the host; otherwise
+// we answer the host's requests for the pool over the kernel device
+// ourselves until it stops asking. Since that holds up the boot, it's only
+// done once Ignition is finished: after the final stage, or after a stage
+// fails.
+func reportStatus(stageName string, f resource.Fetcher, statusErr error) error {
+	result := "succeeded"
+	if statusErr != nil {
+		result = "failed: " + statusErr.Error()
+	}
+	f.Logger.Info("reporting status to Hyper-V host")
+	pairs, err := setPoolValues(guestPoolPath, kvp.ValuePairs{
+		{Key: statusKeyPrefix + stageName, Value: result},
+		{Key: statusKey, Value: stageName + " " + result},
+	})
+	if err != nil {
+		return err
+	}
+	if statusErr == nil && stageName != finalStage {
+		return nil
+	}
+	return sendPool(f.Logger, pairs)
+}
+
+// sendPool serves pairs as the guest pool over the kernel device.
+func sendPool(logger *log.Logger, pairs kvp.ValuePairs) error {
+	fd, err := unix.Open(devicePath, unix.O_RDWR|unix.O_CLOEXEC|unix.O_NONBLOCK, 0)
+	if errors.Is(err, unix.ENOENT) {
+		logger.Info("KVP device %q not found; not sending status", devicePath)
+		return nil
+	} else if errors.Is(err, unix.EBUSY) {
+		// only one process can register with the device
+		logger.Debug("KVP device %q is busy; leaving status to hv_kvp_daemon", devicePath)
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("opening KVP device: %w", err)
+	}
+	defer unix.Close(fd)
+	return servePool(fd, pairs)
+}
+
+// servePool registers with the KVP device on fd and answers host requests
+// as hv_kvp_daemon would, until the host is idle for serveTimeout or
+// maxServeTime has passed.
+func servePool(fd int, pairs kvp.ValuePairs) error {
+	deadline := time.Now().Add(maxServeTime)
+	msg := make([]byte, msgSize)
+	msg[0] = kvp.OpRegister1
+	if err := writeMessage(fd, msg); err != nil {
+		return fmt.Errorf("registering with KVP device: %w", err)
+	}
+
+	for {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return nil
+		}
+		timeout := min(serveTimeout, int(remaining.Milliseconds())+1)
+		ready, err := unix.Poll([]unix.PollFd{{Fd: int32(fd), Events: unix.POLLIN}}, timeout)
+		if err == unix.EINTR {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("polling KVP device: %w", err)
+		}
+		if ready == 0 {
+			return nil
+		}
+
+		n, err := unix.Read(fd, msg)
+		if err == unix.EAGAIN || err == unix.EINTR {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("reading KVP device: %w", err)
+		}
+		if n < minHostMsgSize {
+			return kvp.ErrUnableToReadFromKVP
+		}
+		if msg[0] == kvp.OpRegister1 {
+			// the kernel acknowledging our registration
+			continue
+		}
+		answerRequest(msg[:n], pairs)
+		if err := writeMessage(fd, msg[:n]); err != nil {
+			return fmt.Errorf("answering KVP request: %w", err)
+		}
+	}
+}
+
+func writeMessage(fd int, msg []byte) error {
+	n, err := unix.Write(fd, msg)
+	if err != nil {
+		return err
+	}
+	if n != len(msg) {
+		return kvp.ErrUnableToWriteToKVP
+	}
+	return nil
+}
+
+// answerRequest replaces the host request in msg with the reply. Only
+// the guest pool is served; as with hv_kvp_daemon, a missing key or an
+// index past the end of the pool is answered with HV_S_CONT.
+func answerRequest(msg []byte, pairs kvp.ValuePairs) {
+	op, pool := msg[0], kvp.PoolID(msg[1])
+	result := uint32(kvp.HvSOk)
+	switch op {
+	case opGet:
+		value := msg[getValueOffset : getValueOffset+valueSize]
+		pair, err := pairs.GetValueByKey(string(cString(value[valueKeyOffset:valueValueOffset])))
+		if pool != guestPoolID || err != nil {
+			result = hvSCont
+			break
+		}
+		putCString(value[valueValueOffset:], pair.Value)
+	case opEnumerate:
+		index := binary.NativeEndian.Uint32(msg[enumIndexOffset:])
+		value := msg[enumValueOffset : enumValueOffset+valueSize]
+		if pool != guestPoolID || index >= uint32(len(pairs)) {
+			result = hvSCont
+			break
+		}
+		putCString(value[valueKeyOffset:valueValueOffset], pairs[index].Key)
+		putCString(value[valueValueOffset:], pairs[index].Value)
+	default:
+		// we can't store values from the host or report IP
+		// addresses
+		result = kvp.HvEFail
+	}
+	binary.NativeEndian.PutUint32(msg, result)
+}
+
+// putCString writes s NUL-padded into b, which must be longer than s.
+func putCString(b []byte, s string) {
+	clear(b)
+	copy(b, s)
+}
+
+// setPoolValues adds the pairs to the pool file, replacing any existing
+// values of their keys and preserving the other records, and returns the
+// updated records.
+func setPoolValues(path string, pairs kvp.ValuePairs) (kvp.ValuePairs, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("creating pool directory: %w", err)
+	}
+	// the pool is only for the host, so as with the pools written by
+	// fetchConfig, don't let other users read it
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("opening pool file: %w", err)
+	}
+	defer file.Close()
+
+	// hv_kvp_daemon holds a write lock on the pool file while it
+	// reads or updates it
+	lock := unix.Flock_t{Type: unix.F_WRLCK, Whence: io.SeekStart}
+	if err := unix.FcntlFlock(file.Fd(), unix.F_SETLKW, &lock); err != nil {
+		return nil, fmt.Errorf("locking pool file: %w", err)
+	}
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, fmt.Errorf("reading pool file: %w", err)
+	}
+	records := decodePool(data)
+	// leave room for the NUL terminators the host expects
+	for _, pair := range pairs {
+		records = setValue(records, kvp.ValuePair{
+			Key:   truncate(pair.Key, kvp.HvKvpExchangeMaxKeySize-1),
+			Value: truncate(pair.Value, kvp.HvKvpExchangeMaxValueSize-1),
+		})
+	}
+
+	encoded := kvp.KeyValuePair{guestPoolID: records}.EncodePoolFile(guestPoolID)
+	if err := file.Truncate(0); err != nil {
+		return nil, fmt.Errorf("truncating pool file: %w", err)
+	}
+	if _, err := file.WriteAt(encoded, 0); err != nil {
+		return nil, fmt.Errorf("writing pool file: %w", err)
+	}
+	return records, nil
+}
+
+// decodePool parses the fixed-size, NUL-padded records of a pool file,
+// ignoring any trailing partial record.
+func decodePool(data []byte) kvp.ValuePairs {
+	var pairs kvp.ValuePairs
+	for len(data) >= poolRecordSize {
+		key := data[:kvp.HvKvpExchangeMaxKeySize]
+		value := data[kvp.HvKvpExchangeMaxKeySize:poolRecordSize]
+		pairs = append(pairs, kvp.ValuePair{
+			Key:   string(cString(key)),
+			Value: string(cString(value)),
+		})
+		data = data[poolRecordSize:]
+	}
+	return pairs
+}
+
+func setValue(pairs kvp.ValuePairs, pair kvp.ValuePair) kvp.ValuePairs {
+	for i := range pairs {
+		if pairs[i].Key == pair.Key {
+			pairs[i].Value = pair.Value
+			return pairs
+		}
+	}
+	return append(pairs, pair)
+}
+
+func cString(b []byte) []byte {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		return b[:i]
+	}
+	return b
+}
+
+// truncate shortens s to at most n bytes without splitting a UTF-8
+// sequence.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
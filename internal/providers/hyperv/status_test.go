@@ -0,0 +1,315 @@
+// Copyright 2026 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hyperv
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/containers/libhvee/pkg/kvp"
+	"github.com/coreos/ignition/v2/internal/log"
+	"github.com/coreos/ignition/v2/internal/resource"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+func writePool(t *testing.T, path string, pairs kvp.ValuePairs) {
+	t.Helper()
+	data := kvp.KeyValuePair{guestPoolID: pairs}.EncodePoolFile(guestPoolID)
+	require.NoError(t, os.WriteFile(path, data, 0644))
+}
+
+func readPool(t *testing.T, path string) kvp.ValuePairs {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Zero(t, len(data)%poolRecordSize, "partial record")
+	return decodePool(data)
+}
+
+func TestSetPoolValues(t *testing.T) {
+	dir := t.TempDir()
+
+	// new pool in a missing directory
+	path := filepath.Join(dir, "hyperv", ".kvp_pool_1")
+	pairs, err := setPoolValues(path, kvp.ValuePairs{{Key: "a", Value: "1"}})
+	assert.NoError(t, err)
+	assert.Equal(t, kvp.ValuePairs{{Key: "a", Value: "1"}}, pairs)
+	assert.Equal(t, pairs, readPool(t, path))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// existing records are preserved and updated in place
+	path = filepath.Join(dir, ".kvp_pool_1")
+	writePool(t, path, kvp.ValuePairs{
+		{Key: "other", Value: "kept"},
+		{Key: statusKey, Value: "fetch succeeded"},
+	})
+	_, err = setPoolValues(path, kvp.ValuePairs{
+		{Key: statusKeyPrefix + "disks", Value: "succeeded"},
+		{Key: statusKey, Value: "disks succeeded"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, kvp.ValuePairs{
+		{Key: "other", Value: "kept"},
+		{Key: statusKey, Value: "disks succeeded"},
+		{Key: statusKeyPrefix + "disks", Value: "succeeded"},
+	}, readPool(t, path))
+
+	// long values are truncated on a character boundary
+	path = filepath.Join(dir, "long")
+	long := strings.Repeat("x", kvp.HvKvpExchangeMaxValueSize-2) + "é"
+	_, err = setPoolValues(path, kvp.ValuePairs{{Key: "long", Value: long}})
+	assert.NoError(t, err)
+	assert.Equal(t, kvp.ValuePairs{{Key: "long", Value: long[:kvp.HvKvpExchangeMaxValueSize-2]}}, readPool(t, path))
+}
+
+func TestDecodePool(t *testing.T) {
+	data := kvp.KeyValuePair{guestPoolID: kvp.ValuePairs{
+		{Key: "a", Value: "1"},
+		{Key: "b", Value: ""},
+	}}.EncodePoolFile(guestPoolID)
+	// a partial record is ignored
+	data = append(data, "c"...)
+	assert.Equal(t, kvp.ValuePairs{{Key: "a", Value: "1"}, {Key: "b", Value: ""}}, decodePool(data))
+	assert.Nil(t, decodePool(nil))
+}
+
+func TestReportStatus(t *testing.T) {
+	oldPath, oldDevice := guestPoolPath, devicePath
+	defer func() { guestPoolPath, devicePath = oldPath, oldDevice }()
+	guestPoolPath = filepath.Join(t.TempDir(), ".kvp_pool_1")
+	// a regular file can't be served, so opening it fails the report
+	devicePath = filepath.Join(t.TempDir(), "hv_kvp")
+	require.NoError(t, os.WriteFile(devicePath, nil, 0600))
+
+	logger := log.New(true)
+	f := resource.Fetcher{Logger: &logger}
+	// the pool is only served after the final stage or a failure
+	assert.NoError(t, reportStatus("fetch", f, nil))
+	assert.ErrorIs(t, reportStatus("disks", f, errors.New("creating partitions: boom")), kvp.ErrUnableToReadFromKVP)
+	assert.ErrorIs(t, reportStatus(finalStage, f, nil), kvp.ErrUnableToReadFromKVP)
+	// without the device, the status is only recorded in the pool
+	devicePath = filepath.Join(t.TempDir(), "hv_kvp")
+	assert.NoError(t, reportStatus("disks", f, errors.New("creating partitions: boom")))
+	assert.Equal(t, kvp.ValuePairs{
+		{Key: statusKeyPrefix + "fetch", Value: "succeeded"},
+		{Key: statusKey, Value: "disks failed: creating partitions: boom"},
+		{Key: statusKeyPrefix + "disks", Value: "failed: creating partitions: boom"},
+		{Key: statusKeyPrefix + finalStage, Value: "succeeded"},
+	}, readPool(t, guestPoolPath))
+}
+
+// Mirrors of the structs in linux/hyperv.h, as Go lays them out.
+type hvKvpExchgMsgValue struct {
+	valueType uint32
+	keySize   uint32
+	valueSize uint32
+	key       [kvp.HvKvpExchangeMaxKeySize]uint8
+	value     [kvp.HvKvpExchangeMaxValueSize]uint8
+}
+
+type hvKvpMsgEnumerate struct {
+	index uint32
+	data  hvKvpExchgMsgValue
+}
+
+type hvKvpIPAddrValue struct {
+	adapterID   [128]uint16
+	addrFamily  uint8
+	dhcpEnabled uint8
+	ipAddr      [1024]uint16
+	subNet      [1024]uint16
+	gateWay     [512]uint16
+	dnsAddr     [1024]uint16
+}
+
+type hvKvpHdr struct {
+	operation uint8
+	pool      uint8
+	pad       uint16
+}
+
+func TestMessageLayout(t *testing.T) {
+	var value hvKvpExchgMsgValue
+	var enum hvKvpMsgEnumerate
+	var hdr hvKvpHdr
+	// the body is a union, padded to the alignment of its members
+	align := unsafe.Alignof(enum)
+	body := max(unsafe.Sizeof(enum), unsafe.Sizeof(hvKvpIPAddrValue{}))
+	body = (body + align - 1) / align * align
+
+	assert.Equal(t, int(unsafe.Sizeof(hdr)+body), msgSize, "message size")
+	assert.Equal(t, int(unsafe.Sizeof(int32(0))), msgResultSize, "result size")
+	assert.Equal(t, int(unsafe.Sizeof(hdr)), getValueOffset, "get value offset")
+	assert.Equal(t, int(unsafe.Sizeof(hdr)+unsafe.Offsetof(enum.index)), enumIndexOffset, "enumerate index offset")
+	assert.Equal(t, int(unsafe.Sizeof(hdr)+unsafe.Offsetof(enum.data)), enumValueOffset, "enumerate value offset")
+	assert.Equal(t, int(unsafe.Offsetof(value.key)), valueKeyOffset, "key offset")
+	assert.Equal(t, int(unsafe.Offsetof(value.value)), valueValueOffset, "value offset")
+	assert.Equal(t, int(unsafe.Sizeof(value)), valueSize, "value size")
+}
+
+// hostRequest builds a request from the host, with key as the key of a get
+// or index as the index of an enumerate.
+func hostRequest(op uint8, pool kvp.PoolID, key string, index uint32) []byte {
+	msg := make([]byte, msgSize)
+	msg[0], msg[1] = op, byte(pool)
+	switch op {
+	case opGet:
+		copy(msg[getValueOffset+valueKeyOffset:], key)
+	case opEnumerate:
+		binary.NativeEndian.PutUint32(msg[enumIndexOffset:], index)
+	}
+	return msg
+}
+
+func TestServePool(t *testing.T) {
+	oldTimeout := serveTimeout
+	defer func() { serveTimeout = oldTimeout }()
+	serveTimeout = 100
+
+	// stand in for the kernel device, which preserves message
+	// boundaries
+	fds, err := unix.Socketpair(unix.AF_UNIX, unix.SOCK_SEQPACKET, 0)
+	require.NoError(t, err)
+	host := os.NewFile(uintptr(fds[1]), "host")
+	defer host.Close()
+
+	pairs := kvp.ValuePairs{
+		{Key: statusKeyPrefix + "fetch", Value: "succeeded"},
+		{Key: statusKey, Value: "fetch succeeded"},
+	}
+	done := make(chan error)
+	go func() {
+		defer unix.Close(fds[0])
+		done <- servePool(fds[0], pairs)
+	}()
+
+	msg := make([]byte, msgSize)
+	n, err := host.Read(msg)
+	require.NoError(t, err)
+	require.Equal(t, msgSize, n)
+	assert.Equal(t, byte(kvp.OpRegister1), msg[0], "expected registration")
+	// the kernel acknowledges the registration
+	_, err = host.Write(msg)
+	require.NoError(t, err)
+
+	tests := []struct {
+		request []byte
+		result  uint32
+		key     string
+		value   string
+	}{
+		{
+			request: hostRequest(opEnumerate, guestPoolID, "", 0),
+			result:  kvp.HvSOk,
+			key:     statusKeyPrefix + "fetch",
+			value:   "succeeded",
+		},
+		{
+			request: hostRequest(opEnumerate, guestPoolID, "", 1),
+			result:  kvp.HvSOk,
+			key:     statusKey,
+			value:   "fetch succeeded",
+		},
+		{
+			request: hostRequest(opEnumerate, guestPoolID, "", 2),
+			result:  hvSCont,
+		},
+		{
+			request: hostRequest(opGet, guestPoolID, statusKey, 0),
+			result:  kvp.HvSOk,
+			key:     statusKey,
+			value:   "fetch succeeded",
+		},
+		{
+			request: hostRequest(opGet, guestPoolID, "missing", 0),
+			result:  hvSCont,
+			key:     "missing",
+		},
+		{
+			request: hostRequest(kvp.OpSet, guestPoolID, "", 0),
+			result:  kvp.HvEFail,
+		},
+		// other pools are empty
+		{
+			request: hostRequest(opEnumerate, kvp.DefaultKVPPoolID, "", 0),
+			result:  hvSCont,
+		},
+	}
+
+	for i, test := range tests {
+		op := test.request[0]
+		_, err := host.Write(test.request)
+		require.NoError(t, err, "#%d", i)
+		n, err := host.Read(msg)
+		require.NoError(t, err, "#%d", i)
+		require.Equal(t, msgSize, n, "#%d", i)
+		assert.Equal(t, test.result, binary.NativeEndian.Uint32(msg), "#%d: bad result", i)
+		value := msg[getValueOffset : getValueOffset+valueSize]
+		if op == opEnumerate {
+			value = msg[enumValueOffset : enumValueOffset+valueSize]
+		}
+		assert.Equal(t, test.key, string(cString(value[valueKeyOffset:valueValueOffset])), "#%d: bad key", i)
+		assert.Equal(t, test.value, string(cString(value[valueValueOffset:])), "#%d: bad value", i)
+	}
+
+	// the server stops once the host is idle
+	assert.NoError(t, <-done)
+}
+
+func TestServePoolDeadline(t *testing.T) {
+	oldTimeout, oldMax := serveTimeout, maxServeTime
+	defer func() { serveTimeout, maxServeTime = oldTimeout, oldMax }()
+	serveTimeout = 1000
+	maxServeTime = 300 * time.Millisecond
+
+	fds, err := unix.Socketpair(unix.AF_UNIX, unix.SOCK_SEQPACKET, 0)
+	require.NoError(t, err)
+	host := os.NewFile(uintptr(fds[1]), "host")
+	defer host.Close()
+
+	done := make(chan error)
+	go func() {
+		defer unix.Close(fds[0])
+		done <- servePool(fds[0], nil)
+	}()
+
+	// a host which keeps asking doesn't hold up the boot forever
+	start := time.Now()
+	msg := make([]byte, msgSize)
+	_, err = host.Read(msg)
+	require.NoError(t, err)
+	request := hostRequest(opEnumerate, guestPoolID, "", 0)
+	for {
+		select {
+		case err := <-done:
+			assert.NoError(t, err)
+			assert.Less(t, time.Since(start), 2*time.Second)
+			return
+		case <-time.After(50 * time.Millisecond):
+			_, _ = host.Write(request)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+// Copyright 2026 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The incus provider fetches a configuration from the user data of an Incus
+// or LXD virtual machine, which is written to the instance's config drive.
+// The config drive is shared with the VM over virtiofs or 9p, so it's
+// available in the initramfs, unlike the dev socket served by the agent.
+
+package incus
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/coreos/ignition/v2/config/v3_7_experimental/types"
+	"github.com/coreos/ignition/v2/internal/distro"
+	"github.com/coreos/ignition/v2/internal/log"
+	"github.com/coreos/ignition/v2/internal/platform"
+	"github.com/coreos/ignition/v2/internal/providers/util"
+	"github.com/coreos/ignition/v2/internal/resource"
+	ut "github.com/coreos/ignition/v2/internal/util"
+
+	"github.com/coreos/vcontext/report"
+)
+
+const (
+	// mount tag of the config drive share
+	configDriveTag = "config"
+	// the user data from the cloud-init.user-data config key, or the
+	// older user.user-data key
+	userdataPath = "cloud-init/user-data"
+	// how long to wait for the config drive to become available
+	configDriveTimeout = 30 * time.Second
+)
+
+// Filesystems over which the config drive may be shared, in order. Incus
+// uses virtiofs if virtiofsd is available on the host, and 9p otherwise.
+var shareMounts = []struct {
+	fstype  string
+	options string
+}{
+	{"virtiofs", "ro"},
+	{"9p", "ro,trans=virtio,version=9p2000.L"},
+}
+
+func init() {
+	platform.Register(platform.Provider{
+		Name:  "incus",
+		Fetch: fetchConfig,
+	})
+}
+
+func fetchConfig(f *resource.Fetcher) (types.Config, report.Report, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), configDriveTimeout)
+	defer cancel()
+
+	data, err := fetchFromConfigDrive(f.Logger, ctx)
+	if err == context.DeadlineExceeded {
+		f.Logger.Info("config drive was not available in time. Continuing without a config...")
+		return util.ParseConfig(f.Logger, nil)
+	} else if err != nil {
+		return types.Config{}, report.Report{}, err
+	}
+
+	return util.ParseConfig(f.Logger, data)
+}
+
+// fetchFromConfigDrive waits for the config drive to be mountable and
+// returns the user data from it, or nil if the instance has none.
+func fetchFromConfigDrive(logger *log.Logger, ctx context.Context) ([]byte, error) {
+	logger.Debug("creating temporary mount point")
+	mnt, err := os.MkdirTemp("", "ignition-incus")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %v", err)
+	}
+	defer func() {
+		if removeErr := os.Remove(mnt); removeErr != nil {
+			logger.Warning("failed to remove temp directory %q: %v", mnt, removeErr)
+		}
+	}()
+
+	if err := waitForMount(logger, ctx, mnt, mountConfigDrive); err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = logger.LogOp(
+			func() error {
+				return ut.UmountPath(mnt)
+			},
+			"unmounting config drive at %q", mnt,
+		)
+	}()
+
+	return readUserdata(logger, mnt)
+}
+
+// waitForMount retries mount at mnt every second until it succeeds or ctx
+// is done. The share only appears once the virtio device has been probed.
+func waitForMount(logger *log.Logger, ctx context.Context, mnt string, mount func(string) error) error {
+	for {
+		err := mount(mnt)
+		if err == nil {
+			return nil
+		}
+		logger.Debug("couldn't mount config drive: %v. Waiting...", err)
+		select {
+		case <-time.After(time.Second):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// mountConfigDrive mounts the config drive share read-only at mnt.
+func mountConfigDrive(mnt string) error {
+	var errs []error
+	for _, m := range shareMounts {
+		cmd := exec.Command(distro.MountCmd(), "-o", m.options, "-t", m.fstype, configDriveTag, mnt)
+		out, err := cmd.CombinedOutput()
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %v: %q", m.fstype, err, out))
+	}
+	return fmt.Errorf("%v", errs)
+}
+
+// readUserdata returns the user data from the config drive mounted at mnt,
+// or nil if there is none.
+func readUserdata(logger *log.Logger, mnt string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(mnt, userdataPath))
+	if os.IsNotExist(err) {
+		logger.Info("instance has no user data")
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	logger.Info("read user data from config drive")
+	return data, nil
+}
@@ -0,0 +1,71 @@
+// Copyright 2026 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package incus
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/coreos/ignition/v2/internal/log"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadUserdata(t *testing.T) {
+	logger := log.New(true)
+
+	// no user data
+	mnt := t.TempDir()
+	data, err := readUserdata(&logger, mnt)
+	assert.NoError(t, err)
+	assert.Nil(t, data)
+
+	assert.NoError(t, os.MkdirAll(filepath.Join(mnt, "cloud-init"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(mnt, "cloud-init", "user-data"), []byte(`{"ignition":{}}`), 0644))
+	data, err = readUserdata(&logger, mnt)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"ignition":{}}`, string(data))
+}
+
+func TestWaitForMount(t *testing.T) {
+	logger := log.New(true)
+	errNotReady := errors.New("not ready")
+
+	// the share appears after a while
+	attempts := 0
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	err := waitForMount(&logger, ctx, t.TempDir(), func(string) error {
+		attempts++
+		if attempts < 2 {
+			return errNotReady
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, attempts)
+
+	// the share never appears
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	err = waitForMount(&logger, ctx, t.TempDir(), func(string) error {
+		return errNotReady
+	})
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
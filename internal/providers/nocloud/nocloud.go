@@ -0,0 +1,252 @@
+// Copyright 2026 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The NoCloud provider fetches configurations from the user-data of a
+// cloud-init NoCloud seed. The seed is read from a filesystem labeled
+// "cidata", or from the URL given by the "ds=nocloud;s=" kernel argument.
+
+package nocloud
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/ignition/v2/config/v3_7_experimental/types"
+	"github.com/coreos/ignition/v2/internal/distro"
+	"github.com/coreos/ignition/v2/internal/log"
+	"github.com/coreos/ignition/v2/internal/platform"
+	"github.com/coreos/ignition/v2/internal/providers/util"
+	"github.com/coreos/ignition/v2/internal/resource"
+	ut "github.com/coreos/ignition/v2/internal/util"
+
+	"github.com/coreos/vcontext/report"
+)
+
+const (
+	userdataPath = "user-data"
+	cmdlineFlag  = "ds"
+	// how long to wait for a seed filesystem to appear
+	deviceTimeout = 30 * time.Second
+)
+
+var (
+	// iso9660 seeds are usually labeled in lowercase, and vfat seeds in
+	// uppercase
+	deviceLabels = []string{"cidata", "CIDATA"}
+
+	cloudConfigHeader = []byte("#cloud-config")
+	scriptHeader      = []byte("#!")
+)
+
+func init() {
+	platform.Register(platform.Provider{
+		Name:  "nocloud",
+		Fetch: fetchConfig,
+	})
+}
+
+func fetchConfig(f *resource.Fetcher) (types.Config, report.Report, error) {
+	args, err := os.ReadFile(distro.KernelCmdlinePath())
+	if err != nil {
+		f.Logger.Err("couldn't read cmdline: %v", err)
+		return types.Config{}, report.Report{}, err
+	}
+
+	var data []byte
+	if seed := parseCmdline(args); seed != "" {
+		data, err = fetchConfigFromSeedURL(f, seed)
+	} else {
+		data, err = fetchConfigFromDevices(f)
+	}
+	if err != nil {
+		return types.Config{}, report.Report{}, err
+	}
+
+	return util.ParseConfig(f.Logger, filterUserdata(f.Logger, data))
+}
+
+// parseCmdline returns the seed URL from a "ds=nocloud;s=<url>" or
+// "ds=nocloud;seedfrom=<url>" kernel argument, if any.
+func parseCmdline(cmdline []byte) (seed string) {
+	for _, arg := range strings.Fields(string(cmdline)) {
+		parts := strings.SplitN(arg, "=", 2)
+		if parts[0] != cmdlineFlag || len(parts) != 2 {
+			continue
+		}
+
+		options := strings.Split(parts[1], ";")
+		if options[0] != "nocloud" && options[0] != "nocloud-net" {
+			continue
+		}
+		for _, option := range options[1:] {
+			key, value, _ := strings.Cut(option, "=")
+			if key == "s" || key == "seedfrom" {
+				seed = value
+			}
+		}
+	}
+
+	return
+}
+
+func fetchConfigFromSeedURL(f *resource.Fetcher, seed string) ([]byte, error) {
+	// like cloud-init, treat the seed as a directory
+	if !strings.HasSuffix(seed, "/") {
+		seed += "/"
+	}
+	u, err := url.Parse(seed + userdataPath)
+	if err != nil {
+		f.Logger.Err("failed to parse seed url: %v", err)
+		return nil, err
+	}
+	f.Logger.Debug("fetching user-data from seed %q", seed)
+
+	data, err := f.FetchToBuffer(*u, resource.FetchOptions{})
+	if err == resource.ErrNotFound {
+		f.Logger.Info("seed has no user-data")
+		return nil, nil
+	}
+	return data, err
+}
+
+func fetchConfigFromDevices(f *resource.Fetcher) ([]byte, error) {
+	return fetchConfigFromLabels(f, distro.DiskByLabelDir(), func(ctx context.Context, path string) ([]byte, error) {
+		return fetchConfigFromDevice(f.Logger, ctx, path)
+	})
+}
+
+// fetchConfigFromLabels waits for a seed with one of deviceLabels to appear
+// in labelDir, and returns the user-data read from it by fetch.
+func fetchConfigFromLabels(f *resource.Fetcher, labelDir string, fetch func(context.Context, string) ([]byte, error)) ([]byte, error) {
+	var data []byte
+	errChan := make(chan error)
+	ctx, cancel := context.WithTimeout(context.Background(), deviceTimeout)
+	defer cancel()
+	dispatchCount := 0
+	var once sync.Once
+
+	dispatch := func(name string, fn func() ([]byte, error)) {
+		dispatchCount++
+		go func() {
+			raw, err := fn()
+			if err != nil {
+				switch err {
+				case context.Canceled:
+				case context.DeadlineExceeded:
+					f.Logger.Err("timed out while fetching config from %s", name)
+				default:
+					f.Logger.Err("failed to fetch config from %s: %v", name, err)
+				}
+				errChan <- err
+				return
+			}
+
+			once.Do(func() {
+				data = raw
+				cancel()
+			})
+		}()
+	}
+
+	for _, label := range deviceLabels {
+		dispatch(fmt.Sprintf("seed (%s)", label), func() ([]byte, error) {
+			return fetch(ctx, filepath.Join(labelDir, label))
+		})
+	}
+
+Loop:
+	for {
+		select {
+		case <-ctx.Done():
+			if ctx.Err() == context.DeadlineExceeded {
+				f.Logger.Info("NoCloud seed was not available in time. Continuing without a config...")
+			}
+			break Loop
+		case <-errChan:
+			dispatchCount--
+			if dispatchCount == 0 {
+				f.Logger.Info("couldn't fetch config")
+				break Loop
+			}
+		}
+	}
+
+	return data, nil
+}
+
+// filterUserdata drops user-data meant for cloud-init rather than Ignition.
+func filterUserdata(logger *log.Logger, data []byte) []byte {
+	trimmed := bytes.TrimSpace(data)
+	if bytes.HasPrefix(trimmed, cloudConfigHeader) || bytes.HasPrefix(trimmed, scriptHeader) {
+		logger.Info("user-data is meant for cloud-init, ignoring")
+		return nil
+	}
+	return data
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return (err == nil)
+}
+
+func fetchConfigFromDevice(logger *log.Logger, ctx context.Context, path string) ([]byte, error) {
+	for !fileExists(path) {
+		logger.Debug("seed (%q) not found. Waiting...", path)
+		select {
+		case <-time.After(time.Second):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	logger.Debug("creating temporary mount point")
+	mnt, err := os.MkdirTemp("", "ignition-nocloud")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %v", err)
+	}
+	defer func() {
+		if removeErr := os.Remove(mnt); removeErr != nil {
+			logger.Warning("failed to remove temp directory %q: %v", mnt, removeErr)
+		}
+	}()
+
+	cmd := exec.Command(distro.MountCmd(), "-o", "ro", "-t", "auto", path, mnt)
+	if _, err := logger.LogCmd(cmd, "mounting NoCloud seed"); err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = logger.LogOp(
+			func() error {
+				return ut.UmountPath(mnt)
+			},
+			"unmounting %q at %q", path, mnt,
+		)
+	}()
+
+	mntUserdataPath := filepath.Join(mnt, userdataPath)
+	if !fileExists(mntUserdataPath) {
+		logger.Info("seed (%q) has no user-data", path)
+		return nil, nil
+	}
+
+	return os.ReadFile(mntUserdataPath)
+}
@@ -0,0 +1,126 @@
+// Copyright 2026 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nocloud
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/coreos/ignition/v2/internal/log"
+	"github.com/coreos/ignition/v2/internal/resource"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCmdline(t *testing.T) {
+	tests := []struct {
+		cmdline string
+		seed    string
+	}{
+		{"", ""},
+		{"root=/dev/sda1 ds=nocloud", ""},
+		{"ds=nocloud;s=http://10.0.0.1/seed/", "http://10.0.0.1/seed/"},
+		{"quiet ds=nocloud-net;s=https://example.com/seed/ console=ttyS0\n", "https://example.com/seed/"},
+		{"ds=nocloud;i=iid-01;seedfrom=http://10.0.0.1/seed/", "http://10.0.0.1/seed/"},
+		{"ds=configdrive;s=http://10.0.0.1/seed/", ""},
+		{"ds=nocloud;s=http://10.0.0.1/a/ ds=nocloud;s=http://10.0.0.1/b/", "http://10.0.0.1/b/"},
+	}
+
+	for i, test := range tests {
+		assert.Equal(t, test.seed, parseCmdline([]byte(test.cmdline)), "#%d: bad seed", i)
+	}
+}
+
+func TestFetchConfigFromSeedURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/seed/user-data":
+			_, _ = w.Write([]byte(`{"ignition":{}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		seed string
+		out  string
+	}{
+		{server.URL + "/seed/", `{"ignition":{}}`},
+		// the seed is a directory even without a trailing slash
+		{server.URL + "/seed", `{"ignition":{}}`},
+		// a seed without user-data is not an error
+		{server.URL + "/empty/", ""},
+	}
+
+	logger := log.New(true)
+	f := resource.Fetcher{Logger: &logger}
+	for i, test := range tests {
+		data, err := fetchConfigFromSeedURL(&f, test.seed)
+		assert.NoError(t, err, "#%d: unexpected error", i)
+		assert.Equal(t, test.out, string(data), "#%d: bad user-data", i)
+	}
+}
+
+func TestFilterUserdata(t *testing.T) {
+	tests := []struct {
+		in  string
+		out string
+	}{
+		{"", ""},
+		{`{"ignition":{"version":"3.0.0"}}`, `{"ignition":{"version":"3.0.0"}}`},
+		{"#cloud-config\nusers: []\n", ""},
+		{"\n  #cloud-config\n", ""},
+		{"#!/bin/sh\necho hi\n", ""},
+	}
+
+	logger := log.New(true)
+	for i, test := range tests {
+		assert.Equal(t, test.out, string(filterUserdata(&logger, []byte(test.in))), "#%d: bad user-data", i)
+	}
+}
+
+func TestFetchConfigFromLabels(t *testing.T) {
+	logger := log.New(true)
+	f := resource.Fetcher{Logger: &logger}
+	// read the label back as the user-data, waiting for the device to
+	// appear like fetchConfigFromDevice
+	fetch := func(ctx context.Context, path string) ([]byte, error) {
+		if _, err := os.Stat(path); err != nil {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		return []byte(filepath.Base(path)), nil
+	}
+
+	for _, label := range []string{"cidata", "CIDATA"} {
+		dir := t.TempDir()
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, label), nil, 0644))
+		data, err := fetchConfigFromLabels(&f, dir, fetch)
+		assert.NoError(t, err, "%s: unexpected error", label)
+		assert.Equal(t, label, string(data), "%s: bad user-data", label)
+	}
+
+	// failing to read both seeds isn't an error
+	data, err := fetchConfigFromLabels(&f, t.TempDir(), func(ctx context.Context, path string) ([]byte, error) {
+		return nil, os.ErrPermission
+	})
+	assert.NoError(t, err)
+	assert.Nil(t, data)
+}
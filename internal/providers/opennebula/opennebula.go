@@ -0,0 +1,233 @@
+// Copyright 2026 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The opennebula provider fetches a configuration from the USER_DATA
+// variable of the OpenNebula context CD-ROM.
+
+package opennebula
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/coreos/ignition/v2/config/v3_7_experimental/types"
+	"github.com/coreos/ignition/v2/internal/distro"
+	"github.com/coreos/ignition/v2/internal/log"
+	"github.com/coreos/ignition/v2/internal/platform"
+	"github.com/coreos/ignition/v2/internal/providers/util"
+	"github.com/coreos/ignition/v2/internal/resource"
+	ut "github.com/coreos/ignition/v2/internal/util"
+
+	"github.com/coreos/vcontext/report"
+)
+
+const (
+	deviceLabel = "CONTEXT"
+	contextPath = "context.sh"
+	// how long to wait for the context device to appear
+	deviceTimeout = 30 * time.Second
+)
+
+var (
+	ErrContextSyntax = errors.New("invalid context.sh syntax")
+)
+
+func init() {
+	platform.Register(platform.Provider{
+		Name:  "opennebula",
+		Fetch: fetchConfig,
+	})
+}
+
+func fetchConfig(f *resource.Fetcher) (types.Config, report.Report, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), deviceTimeout)
+	defer cancel()
+
+	raw, err := fetchContextFromDevice(f.Logger, ctx, filepath.Join(distro.DiskByLabelDir(), deviceLabel))
+	if err == context.DeadlineExceeded {
+		f.Logger.Info("context device was not available in time. Continuing without a config...")
+		return util.ParseConfig(f.Logger, nil)
+	} else if err != nil {
+		f.Logger.Err("failed to fetch context: %v", err)
+		return types.Config{}, report.Report{}, err
+	}
+
+	vars, err := parseContext(raw)
+	if err != nil {
+		f.Logger.Err("failed to parse context: %v", err)
+		return types.Config{}, report.Report{}, err
+	}
+	data, err := userdata(vars)
+	if err != nil {
+		f.Logger.Err("failed to decode user data: %v", err)
+		return types.Config{}, report.Report{}, err
+	}
+
+	return util.ParseConfig(f.Logger, data)
+}
+
+// userdata returns the decoded user data from the context variables, or nil
+// if there is none.
+func userdata(vars map[string]string) ([]byte, error) {
+	value, ok := vars["USER_DATA"]
+	if !ok {
+		value = vars["USERDATA"]
+	}
+	if value == "" {
+		return nil, nil
+	}
+
+	data := []byte(value)
+	switch encoding := vars["USERDATA_ENCODING"]; encoding {
+	case "":
+	case "base64":
+		var err error
+		if data, err = base64.StdEncoding.DecodeString(strings.TrimSpace(value)); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported user data encoding %q", encoding)
+	}
+	return util.TryUnzip(data)
+}
+
+// parseContext parses the variable assignments in a context.sh file without
+// executing it. Values can be unquoted, single-quoted, or double-quoted,
+// and quoted values can span lines. Expansions and other shell syntax are
+// rejected rather than interpreted.
+func parseContext(raw []byte) (map[string]string, error) {
+	vars := make(map[string]string)
+	s := string(raw)
+	for i := 0; i < len(s); {
+		switch c := s[i]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ';':
+			i++
+			continue
+		case c == '#':
+			for i < len(s) && s[i] != '\n' {
+				i++
+			}
+			continue
+		}
+
+		start := i
+		for i < len(s) && isNameChar(s[i], i == start) {
+			i++
+		}
+		if i == start || i >= len(s) || s[i] != '=' {
+			return nil, fmt.Errorf("%w: expected assignment at offset %d", ErrContextSyntax, start)
+		}
+		name := s[start:i]
+		i++
+
+		var value strings.Builder
+	Value:
+		for i < len(s) {
+			switch c := s[i]; c {
+			case ' ', '\t', '\n', '\r', ';':
+				break Value
+			case '\'':
+				end := strings.IndexByte(s[i+1:], '\'')
+				if end < 0 {
+					return nil, fmt.Errorf("%w: unterminated quote in %s", ErrContextSyntax, name)
+				}
+				value.WriteString(s[i+1 : i+1+end])
+				i += end + 2
+			case '"':
+				i++
+				for ; i < len(s) && s[i] != '"'; i++ {
+					if s[i] == '$' || s[i] == '`' {
+						return nil, fmt.Errorf("%w: expansion in %s", ErrContextSyntax, name)
+					}
+					if s[i] == '\\' && i+1 < len(s) && strings.IndexByte("$`\"\\\n", s[i+1]) >= 0 {
+						i++
+						if s[i] == '\n' {
+							continue
+						}
+					}
+					value.WriteByte(s[i])
+				}
+				if i >= len(s) {
+					return nil, fmt.Errorf("%w: unterminated quote in %s", ErrContextSyntax, name)
+				}
+				i++
+			case '\\':
+				if i+1 < len(s) && s[i+1] != '\n' {
+					value.WriteByte(s[i+1])
+				}
+				i += 2
+			case '$', '`', '(', ')', '<', '>', '|', '&':
+				return nil, fmt.Errorf("%w: unquoted %q in %s", ErrContextSyntax, c, name)
+			default:
+				value.WriteByte(c)
+				i++
+			}
+		}
+		vars[name] = value.String()
+	}
+	return vars, nil
+}
+
+func isNameChar(c byte, first bool) bool {
+	return c == '_' || (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (!first && c >= '0' && c <= '9')
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return (err == nil)
+}
+
+func fetchContextFromDevice(logger *log.Logger, ctx context.Context, path string) ([]byte, error) {
+	for !fileExists(path) {
+		logger.Debug("context device (%q) not found. Waiting...", path)
+		select {
+		case <-time.After(time.Second):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	logger.Debug("creating temporary mount point")
+	mnt, err := os.MkdirTemp("", "ignition-opennebula")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %v", err)
+	}
+	defer func() {
+		if removeErr := os.Remove(mnt); removeErr != nil {
+			logger.Warning("failed to remove temp directory %q: %v", mnt, removeErr)
+		}
+	}()
+
+	cmd := exec.Command(distro.MountCmd(), "-o", "ro", "-t", "auto", path, mnt)
+	if _, err := logger.LogCmd(cmd, "mounting context device"); err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = logger.LogOp(
+			func() error {
+				return ut.UmountPath(mnt)
+			},
+			"unmounting %q at %q", path, mnt,
+		)
+	}()
+
+	return os.ReadFile(filepath.Join(mnt, contextPath))
+}
@@ -0,0 +1,134 @@
+// Copyright 2026 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opennebula
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseContext(t *testing.T) {
+	tests := []struct {
+		in  string
+		out map[string]string
+		err bool
+	}{
+		{
+			in:  "",
+			out: map[string]string{},
+		},
+		{
+			in: `# Context variables generated by OpenNebula
+DISK_ID='1'
+ETH0_IP='192.0.2.10'
+SSH_PUBLIC_KEY='ssh-ed25519 AAAA user@host'
+USER_DATA='eyJpZ25pdGlvbiI6e319'
+USERDATA_ENCODING='base64'
+`,
+			out: map[string]string{
+				"DISK_ID":           "1",
+				"ETH0_IP":           "192.0.2.10",
+				"SSH_PUBLIC_KEY":    "ssh-ed25519 AAAA user@host",
+				"USER_DATA":         "eyJpZ25pdGlvbiI6e319",
+				"USERDATA_ENCODING": "base64",
+			},
+		},
+		// quoting and multi-line values
+		{
+			in: "A='it'\\''s'\nB=\"say \\\"hi\\\" \\$HOME\"\nC=plain; D='multi\nline'\nE=\n",
+			out: map[string]string{
+				"A": "it's",
+				"B": `say "hi" $HOME`,
+				"C": "plain",
+				"D": "multi\nline",
+				"E": "",
+			},
+		},
+		// shell syntax is rejected
+		{
+			in:  "A=$(reboot)\n",
+			err: true,
+		},
+		{
+			in:  "A=\"`reboot`\"\n",
+			err: true,
+		},
+		{
+			in:  "A=1 | reboot\n",
+			err: true,
+		},
+		{
+			in:  "reboot\n",
+			err: true,
+		},
+		{
+			in:  "A='unterminated\n",
+			err: true,
+		},
+	}
+
+	for i, test := range tests {
+		out, err := parseContext([]byte(test.in))
+		if test.err {
+			assert.ErrorIs(t, err, ErrContextSyntax, "#%d: expected error", i)
+			continue
+		}
+		assert.NoError(t, err, "#%d: unexpected error", i)
+		assert.Equal(t, test.out, out, "#%d: bad variables", i)
+	}
+}
+
+func TestUserdata(t *testing.T) {
+	tests := []struct {
+		vars map[string]string
+		out  string
+		err  bool
+	}{
+		{
+			vars: map[string]string{},
+		},
+		{
+			vars: map[string]string{"USER_DATA": `{"ignition":{}}`},
+			out:  `{"ignition":{}}`,
+		},
+		{
+			vars: map[string]string{"USER_DATA": "eyJpZ25pdGlvbiI6e319", "USERDATA_ENCODING": "base64"},
+			out:  `{"ignition":{}}`,
+		},
+		{
+			vars: map[string]string{"USERDATA": "eyJpZ25pdGlvbiI6e319", "USERDATA_ENCODING": "base64"},
+			out:  `{"ignition":{}}`,
+		},
+		{
+			vars: map[string]string{"USER_DATA": "!!", "USERDATA_ENCODING": "base64"},
+			err:  true,
+		},
+		{
+			vars: map[string]string{"USER_DATA": "data", "USERDATA_ENCODING": "rot13"},
+			err:  true,
+		},
+	}
+
+	for i, test := range tests {
+		out, err := userdata(test.vars)
+		if test.err {
+			assert.Error(t, err, "#%d: expected error", i)
+			continue
+		}
+		assert.NoError(t, err, "#%d: unexpected error", i)
+		assert.Equal(t, test.out, string(out), "#%d: bad user data", i)
+	}
+}
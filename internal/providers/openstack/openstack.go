@@ -21,12 +21,15 @@ package openstack
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"maps"
 	"net"
 	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -60,17 +63,24 @@ var (
 			Path:   "openstack/latest/user_data",
 		},
 	}
+	metadataURL = url.URL{
+		Scheme: "http",
+		Host:   "169.254.169.254",
+		Path:   "openstack/latest/meta_data.json",
+	}
 )
 
 func init() {
 	platform.Register(platform.Provider{
-		Name:  "openstack",
-		Fetch: fetchConfig,
+		Name:         "openstack",
+		Fetch:        fetchConfig,
+		FetchSSHKeys: fetchSSHKeys,
 	})
 	// the brightbox platform ID just uses the OpenStack provider code
 	platform.Register(platform.Provider{
-		Name:  "brightbox",
-		Fetch: fetchConfig,
+		Name:         "brightbox",
+		Fetch:        fetchConfig,
+		FetchSSHKeys: fetchSSHKeys,
 	})
 }
 
@@ -135,6 +145,34 @@ Loop:
 	return util.ParseConfig(f.Logger, data)
 }
 
+// fetchSSHKeys returns the instance's key pairs from the metadata service,
+// ordered by key pair name.
+func fetchSSHKeys(f *resource.Fetcher) ([]string, error) {
+	if f.Offline {
+		return nil, resource.ErrNeedNet
+	}
+
+	data, err := f.FetchToBuffer(metadataURL, resource.FetchOptions{})
+	if err == resource.ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var metadata struct {
+		PublicKeys map[string]string `json:"public_keys"`
+	}
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return nil, fmt.Errorf("parsing metadata: %w", err)
+	}
+
+	var keys []string
+	for _, name := range slices.Sorted(maps.Keys(metadata.PublicKeys)) {
+		keys = append(keys, util.ParseSSHKeys([]byte(metadata.PublicKeys[name]))...)
+	}
+	return keys, nil
+}
+
 func fileExists(path string) bool {
 	_, err := os.Stat(path)
 	return (err == nil)
@@ -15,8 +15,9 @@
 //go:build !s390x && !ppc64le
 
 // The default QEMU provider fetches a local configuration from the firmware
-// config interface (opt/com.coreos/config). Platforms without support for
-// qemu_fw_cfg should use the blockdev implementation instead.
+// config interface (opt/com.coreos/config), falling back to SMBIOS OEM
+// strings. Platforms without support for qemu_fw_cfg should use the blockdev
+// implementation instead.
 
 package qemu
 
@@ -61,8 +62,13 @@ func fetchConfig(f *resource.Fetcher) (cfg types.Config, rpt report.Report, err
 	var sizeBytes []byte
 	sizeBytes, err = os.ReadFile(firmwareConfigSizePath)
 	if os.IsNotExist(err) {
-		f.Logger.Info("QEMU firmware config was not found. Ignoring...")
-		cfg, rpt, err = util.ParseConfig(f.Logger, []byte{})
+		f.Logger.Info("QEMU firmware config was not found. Checking SMBIOS OEM strings...")
+		var data []byte
+		data, err = fetchConfigFromSMBIOS(f, smbiosEntriesDir)
+		if err != nil {
+			return
+		}
+		cfg, rpt, err = util.ParseConfig(f.Logger, data)
 		return
 	} else if err != nil {
 		f.Logger.Err("couldn't read QEMU firmware config size: %v", err)
@@ -0,0 +1,151 @@
+// Copyright 2026 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !s390x && !ppc64le
+
+// QEMU and libvirt can also pass a config in SMBIOS type 11 OEM strings,
+// using the systemd credential syntax:
+//
+//	io.systemd.credential:ignition.config=<config>
+//	io.systemd.credential.binary:ignition.config=<base64-encoded config>
+//	io.systemd.credential:ignition.config.url=<url>
+
+package qemu
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/coreos/ignition/v2/internal/providers/util"
+	"github.com/coreos/ignition/v2/internal/resource"
+)
+
+const (
+	smbiosEntriesDir = "/sys/firmware/dmi/entries"
+	smbiosOEMStrings = 11
+
+	credentialPrefix       = "io.systemd.credential:"
+	binaryCredentialPrefix = "io.systemd.credential.binary:"
+	configCredential       = "ignition.config"
+	configURLCredential    = "ignition.config.url"
+)
+
+// fetchConfigFromSMBIOS returns the config given in the SMBIOS OEM strings
+// under dir, fetching it if only a URL is given. It returns nil if neither
+// is present.
+func fetchConfigFromSMBIOS(f *resource.Fetcher, dir string) ([]byte, error) {
+	oemStrings, err := readOEMStrings(dir)
+	if err != nil {
+		f.Logger.Err("couldn't read SMBIOS OEM strings: %v", err)
+		return nil, err
+	}
+	credentials, err := parseCredentials(oemStrings)
+	if err != nil {
+		f.Logger.Err("couldn't parse SMBIOS OEM strings: %v", err)
+		return nil, err
+	}
+
+	if data, ok := credentials[configCredential]; ok {
+		f.Logger.Info("found config in SMBIOS OEM strings")
+		return util.TryUnzip(data)
+	}
+	if rawURL, ok := credentials[configURLCredential]; ok {
+		u, err := url.Parse(string(rawURL))
+		if err != nil {
+			f.Logger.Err("failed to parse url from SMBIOS OEM strings: %v", err)
+			return nil, err
+		}
+		f.Logger.Info("fetching config from URL in SMBIOS OEM strings")
+		return f.FetchToBuffer(*u, resource.FetchOptions{})
+	}
+
+	f.Logger.Info("no config in SMBIOS OEM strings")
+	return nil, nil
+}
+
+// readOEMStrings returns the strings of every OEM strings structure in the
+// sysfs DMI entries directory dir, in the order of their instance numbers.
+func readOEMStrings(dir string) ([]string, error) {
+	entries, err := filepath.Glob(filepath.Join(dir, fmt.Sprintf("%d-*", smbiosOEMStrings), "raw"))
+	if err != nil {
+		return nil, err
+	}
+	// the glob sorts 11-10 before 11-2
+	instance := func(entry string) int {
+		_, suffix, _ := strings.Cut(filepath.Base(filepath.Dir(entry)), "-")
+		n, _ := strconv.Atoi(suffix)
+		return n
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return instance(entries[i]) < instance(entries[j])
+	})
+
+	var result []string
+	for _, entry := range entries {
+		raw, err := os.ReadFile(entry)
+		if err != nil {
+			return nil, err
+		}
+		// the formatted area starts with a 4-byte header whose second
+		// byte is its length; the strings follow, each NUL-terminated,
+		// with an extra NUL at the end
+		if len(raw) < 4 || raw[0] != smbiosOEMStrings || int(raw[1]) > len(raw) {
+			return nil, fmt.Errorf("malformed SMBIOS entry %q", entry)
+		}
+		for _, s := range bytes.Split(raw[raw[1]:], []byte{0}) {
+			if len(s) > 0 {
+				result = append(result, string(s))
+			}
+		}
+	}
+	return result, nil
+}
+
+// parseCredentials returns the values of Ignition's systemd credentials in
+// oemStrings. Later strings override earlier ones.
+func parseCredentials(oemStrings []string) (map[string][]byte, error) {
+	credentials := make(map[string][]byte)
+	for _, s := range oemStrings {
+		var binary bool
+		if rest, ok := strings.CutPrefix(s, binaryCredentialPrefix); ok {
+			s, binary = rest, true
+		} else if rest, ok := strings.CutPrefix(s, credentialPrefix); ok {
+			s = rest
+		} else {
+			continue
+		}
+
+		name, value, found := strings.Cut(s, "=")
+		if !found || (name != configCredential && name != configURLCredential) {
+			continue
+		}
+		if !binary {
+			credentials[name] = []byte(value)
+			continue
+		}
+		decoded, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return nil, fmt.Errorf("decoding credential %q: %w", name, err)
+		}
+		credentials[name] = decoded
+	}
+	return credentials, nil
+}
@@ -0,0 +1,155 @@
+// Copyright 2026 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !s390x && !ppc64le
+
+package qemu
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/coreos/ignition/v2/internal/log"
+	"github.com/coreos/ignition/v2/internal/resource"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// oemStringsEntry returns a raw SMBIOS type 11 structure holding strings.
+func oemStringsEntry(strings ...string) []byte {
+	raw := []byte{smbiosOEMStrings, 5, 0x00, 0x20, byte(len(strings))}
+	for _, s := range strings {
+		raw = append(raw, s...)
+		raw = append(raw, 0)
+	}
+	return append(raw, 0)
+}
+
+func gzipBase64(t *testing.T, data string) string {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write([]byte(data))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+func TestFetchConfigFromSMBIOS(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("remote"))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		entries map[string][]byte
+		out     string
+		err     bool
+	}{
+		// no entries
+		{},
+		{
+			entries: map[string][]byte{
+				"11-0": oemStringsEntry("vendor string", "io.systemd.credential:other=value"),
+			},
+		},
+		{
+			entries: map[string][]byte{
+				"11-0": oemStringsEntry("vendor string", "io.systemd.credential:ignition.config=inline"),
+			},
+			out: "inline",
+		},
+		{
+			entries: map[string][]byte{
+				"11-0": oemStringsEntry("io.systemd.credential.binary:ignition.config=" + base64.StdEncoding.EncodeToString([]byte("binary"))),
+			},
+			out: "binary",
+		},
+		{
+			entries: map[string][]byte{
+				"11-0": oemStringsEntry("io.systemd.credential.binary:ignition.config=" + gzipBase64(t, "compressed")),
+			},
+			out: "compressed",
+		},
+		// later entries override earlier ones
+		{
+			entries: map[string][]byte{
+				"11-0": oemStringsEntry("io.systemd.credential:ignition.config=first"),
+				"11-1": oemStringsEntry("io.systemd.credential:ignition.config=second"),
+			},
+			out: "second",
+		},
+		// entries are ordered by instance number
+		{
+			entries: map[string][]byte{
+				"11-2":  oemStringsEntry("io.systemd.credential:ignition.config=first"),
+				"11-10": oemStringsEntry("io.systemd.credential:ignition.config=second"),
+			},
+			out: "second",
+		},
+		// inline configs take precedence over URLs
+		{
+			entries: map[string][]byte{
+				"11-0": oemStringsEntry("io.systemd.credential:ignition.config.url="+server.URL, "io.systemd.credential:ignition.config=inline"),
+			},
+			out: "inline",
+		},
+		{
+			entries: map[string][]byte{
+				"11-0": oemStringsEntry("io.systemd.credential:ignition.config.url=" + server.URL),
+			},
+			out: "remote",
+		},
+		// other structure types are ignored
+		{
+			entries: map[string][]byte{
+				"1-0": {1, 4, 0, 0, 'x', 0, 0},
+			},
+		},
+		{
+			entries: map[string][]byte{
+				"11-0": oemStringsEntry("io.systemd.credential.binary:ignition.config=!!"),
+			},
+			err: true,
+		},
+		{
+			entries: map[string][]byte{
+				"11-0": {smbiosOEMStrings, 5},
+			},
+			err: true,
+		},
+	}
+
+	logger := log.New(true)
+	f := resource.Fetcher{Logger: &logger}
+	for i, test := range tests {
+		dir := t.TempDir()
+		for name, raw := range test.entries {
+			assert.NoError(t, os.Mkdir(filepath.Join(dir, name), 0755))
+			assert.NoError(t, os.WriteFile(filepath.Join(dir, name, "raw"), raw, 0444))
+		}
+		data, err := fetchConfigFromSMBIOS(&f, dir)
+		if test.err {
+			assert.Error(t, err, "#%d: expected error", i)
+			continue
+		}
+		assert.NoError(t, err, "#%d: unexpected error", i)
+		assert.Equal(t, test.out, string(data), "#%d: bad config", i)
+	}
+}
@@ -18,11 +18,11 @@ import (
 	"os"
 	"path/filepath"
 
-	latest "github.com/coreos/ignition/v2/config/v3_7_experimental"
 	"github.com/coreos/ignition/v2/config/v3_7_experimental/types"
 	"github.com/coreos/ignition/v2/internal/distro"
 	"github.com/coreos/ignition/v2/internal/log"
 	"github.com/coreos/ignition/v2/internal/platform"
+	"github.com/coreos/ignition/v2/internal/provenance"
 	"github.com/coreos/ignition/v2/internal/providers/util"
 	"github.com/coreos/ignition/v2/internal/resource"
 
@@ -43,29 +43,70 @@ var (
 )
 
 // FetchBaseConfig fetches base config fragments from the `base.d` and platform config fragments from
-// the `base.platform.d/platform`(if available), and merge them in the right order.
-func FetchBaseConfig(logger *log.Logger, platformName string) (types.Config, report.Report, error) {
-	fullBaseConfig, fullReport, err := fetchBaseDirectoryConfig(logger, "base.d")
+// the `base.platform.d/platform`(if available) of the system config directory dir, and merge them in
+// the right order. If platformName is empty, only `base.d` is read.
+func FetchBaseConfig(logger *log.Logger, dir string, platformName string) (types.Config, report.Report, error) {
+	fullConfig, fullReport, err := fetchLayerConfig(logger, dir, "base", platformName, false)
+	return fullConfig.Config, fullReport, err
+}
+
+// FetchBaseConfigProvenance is FetchBaseConfig, tracking the origins of the
+// fields of the config. They originate from the paths of the fragments.
+func FetchBaseConfigProvenance(logger *log.Logger, dir string, platformName string) (provenance.Config, report.Report, error) {
+	return fetchLayerConfig(logger, dir, "base", platformName, true)
+}
+
+// FetchOverrideConfig fetches override config fragments from the `override.d` and platform config
+// fragments from the `override.platform.d/platform`(if available), and merge them in the right order.
+// Unlike the base config, the override config is merged on top of the user config. It returns
+// platform.ErrNoProvider if there are no override config fragments.
+func FetchOverrideConfig(logger *log.Logger, dir string, platformName string) (types.Config, report.Report, error) {
+	fullOverrideConfig, fullReport, err := fetchOverrideConfig(logger, dir, platformName, false)
+	return fullOverrideConfig.Config, fullReport, err
+}
+
+// FetchOverrideConfigProvenance is FetchOverrideConfig, tracking the
+// origins of the fields of the config. They originate from the paths of
+// the fragments.
+func FetchOverrideConfigProvenance(logger *log.Logger, dir string, platformName string) (provenance.Config, report.Report, error) {
+	return fetchOverrideConfig(logger, dir, platformName, true)
+}
+
+func fetchOverrideConfig(logger *log.Logger, dir string, platformName string, track bool) (provenance.Config, report.Report, error) {
+	fullOverrideConfig, fullReport, err := fetchLayerConfig(logger, dir, "override", platformName, track)
+	if err == nil && fullOverrideConfig.Config.Ignition.Version == "" {
+		// every parsed fragment has a version
+		return provenance.Config{}, fullReport, platform.ErrNoProvider
+	}
+	return fullOverrideConfig, fullReport, err
+}
+
+// fetchLayerConfig merges the config fragments from `<layer>.d` and `<layer>.platform.d/platform`,
+// tracking the origins of their fields if track is set.
+func fetchLayerConfig(logger *log.Logger, dir string, layer string, platformName string, track bool) (provenance.Config, report.Report, error) {
+	fullConfig, fullReport, err := fetchBaseDirectoryConfig(logger, filepath.Join(dir, layer+".d"), track)
 	if err != nil {
-		return types.Config{}, fullReport, err
+		return provenance.Config{}, fullReport, err
+	}
+	if platformName == "" {
+		return fullConfig, fullReport, nil
 	}
 
-	platformDir := filepath.Join("base.platform.d", platformName)
-	basePlatformDConfig, basePlatformDReport, err := fetchBaseDirectoryConfig(logger, platformDir)
+	platformDir := filepath.Join(dir, layer+".platform.d", platformName)
+	platformDConfig, platformDReport, err := fetchBaseDirectoryConfig(logger, platformDir, track)
 	if err != nil {
 		logger.Info("no config at %q: %v", platformDir, err)
 	}
-	fullBaseConfig = latest.Merge(fullBaseConfig, basePlatformDConfig)
-	fullReport.Merge(basePlatformDReport)
-	return fullBaseConfig, fullReport, nil
+	fullConfig = provenance.Merge(fullConfig, platformDConfig)
+	fullReport.Merge(platformDReport)
+	return fullConfig, fullReport, nil
 }
 
 func fetchConfig(f *resource.Fetcher) (types.Config, report.Report, error) {
-	return doFetchConfig(f.Logger, userFilename)
+	return doFetchConfig(f.Logger, filepath.Join(distro.SystemConfigDir(), userFilename))
 }
 
-func doFetchConfig(logger *log.Logger, filename string) (types.Config, report.Report, error) {
-	path := filepath.Join(distro.SystemConfigDir(), filename)
+func doFetchConfig(logger *log.Logger, path string) (types.Config, report.Report, error) {
 	logger.Info("reading system config file %q", path)
 
 	rawConfig, err := os.ReadFile(path)
@@ -80,28 +121,33 @@ func doFetchConfig(logger *log.Logger, filename string) (types.Config, report.Re
 }
 
 // fetchBaseDirectoryConfig is a helper function to merge all the base config fragments inside of a particular directory.
-func fetchBaseDirectoryConfig(logger *log.Logger, dir string) (types.Config, report.Report, error) {
-	var baseConfig types.Config
+// If track is set, the fields of the merged config originate from the paths of the fragments.
+func fetchBaseDirectoryConfig(logger *log.Logger, path string, track bool) (provenance.Config, report.Report, error) {
+	var baseConfig provenance.Config
 	var report report.Report
-	path := filepath.Join(distro.SystemConfigDir(), dir)
 	configs, err := os.ReadDir(path)
 	if os.IsNotExist(err) {
 		logger.Info("no config dir at %q", path)
-		return types.Config{}, report, nil
+		return provenance.Config{}, report, nil
 	} else if err != nil {
 		logger.Err("couldn't read config dir %q: %v", path, err)
-		return types.Config{}, report, err
+		return provenance.Config{}, report, err
 	}
 	if len(configs) == 0 {
 		logger.Info("no configs at %q", path)
-		return types.Config{}, report, nil
+		return provenance.Config{}, report, nil
 	}
 	for _, config := range configs {
-		intermediateConfig, intermediateReport, err := doFetchConfig(logger, filepath.Join(dir, config.Name()))
+		fragmentPath := filepath.Join(path, config.Name())
+		intermediateConfig, intermediateReport, err := doFetchConfig(logger, fragmentPath)
 		if err != nil {
-			return types.Config{}, intermediateReport, err
+			return provenance.Config{}, intermediateReport, err
+		}
+		fragment := provenance.Untracked(intermediateConfig)
+		if track {
+			fragment = provenance.New(fragmentPath, intermediateConfig)
 		}
-		baseConfig = latest.Merge(baseConfig, intermediateConfig)
+		baseConfig = provenance.Merge(baseConfig, fragment)
 		report.Merge(intermediateReport)
 	}
 	return baseConfig, report, nil
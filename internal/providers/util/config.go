@@ -25,7 +25,20 @@ import (
 	"github.com/coreos/vcontext/report"
 )
 
+// ParseConfig parses a config from user data, after unwrapping it with
+// DecodeUserdata and DecryptConfig.
 func ParseConfig(logger *log.Logger, rawConfig []byte) (types.Config, report.Report, error) {
+	rawConfig, err := DecodeUserdata(logger, rawConfig)
+	if err != nil {
+		logger.Err("couldn't decode user data: %v", err)
+		return types.Config{}, report.Report{}, err
+	}
+	rawConfig, err = DecryptConfig(logger, rawConfig)
+	if err != nil {
+		logger.Err("couldn't decrypt config: %v", err)
+		return types.Config{}, report.Report{}, err
+	}
+
 	hash := sha512.Sum512(rawConfig)
 	logger.Debug("parsing config with SHA512: %s", hex.EncodeToString(hash[:]))
 
@@ -0,0 +1,196 @@
+// Copyright 2026 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/coreos/ignition/v2/internal/distro"
+	"github.com/coreos/ignition/v2/internal/log"
+
+	"github.com/go-jose/go-jose/v4"
+)
+
+const (
+	// keysDirName is the directory under the system config dir holding
+	// JWK files with the keys to decrypt configs
+	keysDirName = "config-keys.d"
+	// keyCredential is the systemd credential holding a key, which
+	// platforms can pass separately from the user data
+	keyCredential = "ignition.config.key"
+	// clevisHeader marks JWEs encrypted with clevis, whose keys are
+	// sealed by a TPM2 or Tang server
+	clevisHeader = "clevis"
+)
+
+var (
+	ErrNoDecryptionKey = errors.New("no key could decrypt the encrypted config")
+
+	keyAlgorithms = []jose.KeyAlgorithm{
+		jose.RSA_OAEP, jose.RSA_OAEP_256,
+		jose.ECDH_ES, jose.ECDH_ES_A128KW, jose.ECDH_ES_A192KW, jose.ECDH_ES_A256KW,
+		jose.A128KW, jose.A192KW, jose.A256KW,
+		jose.A128GCMKW, jose.A192GCMKW, jose.A256GCMKW,
+		jose.DIRECT,
+	}
+	contentEncryptions = []jose.ContentEncryption{
+		jose.A128GCM, jose.A192GCM, jose.A256GCM,
+		jose.A128CBC_HS256, jose.A192CBC_HS384, jose.A256CBC_HS512,
+	}
+)
+
+// DecryptConfig returns the config inside a JWE envelope, in compact or JSON
+// serialization. The envelope is decrypted with clevis if it was encrypted
+// with clevis, or else with the keys in the system config dir and the
+// ignition.config.key credential. Other data is returned unchanged.
+func DecryptConfig(logger *log.Logger, data []byte) ([]byte, error) {
+	return decryptConfig(logger, data, loadKeys)
+}
+
+func decryptConfig(logger *log.Logger, data []byte, keys func(*log.Logger) ([]jose.JSONWebKey, error)) ([]byte, error) {
+	if !isEnvelope(data) {
+		return data, nil
+	}
+	jwe, err := jose.ParseEncrypted(string(data), keyAlgorithms, contentEncryptions)
+	if err != nil {
+		return nil, fmt.Errorf("parsing encrypted config: %w", err)
+	}
+
+	if _, ok := jwe.Header.ExtraHeaders[clevisHeader]; ok {
+		logger.Info("decrypting config with clevis")
+		return clevisDecrypt(jwe, data)
+	}
+
+	candidates, err := keys(logger)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range candidates {
+		if jwe.Header.KeyID != "" && key.KeyID != jwe.Header.KeyID {
+			continue
+		}
+		if _, _, plaintext, err := jwe.DecryptMulti(key); err == nil {
+			logger.Info("decrypted config with key %q", key.KeyID)
+			return plaintext, nil
+		}
+	}
+	return nil, ErrNoDecryptionKey
+}
+
+// isEnvelope reports whether the data looks like a JWE. A JWE in JSON
+// serialization always has a ciphertext, which configs never do.
+func isEnvelope(data []byte) bool {
+	data = bytes.TrimSpace(data)
+	if bytes.HasPrefix(data, []byte("{")) {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return false
+		}
+		_, ok := fields["ciphertext"]
+		return ok
+	}
+
+	parts := strings.Split(string(data), ".")
+	if len(parts) != 5 {
+		return false
+	}
+	rawHeader, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return false
+	}
+	var header struct {
+		Enc string `json:"enc"`
+	}
+	return json.Unmarshal(rawHeader, &header) == nil && header.Enc != ""
+}
+
+// clevisDecrypt runs clevis, which only accepts the compact serialization.
+func clevisDecrypt(jwe *jose.JSONWebEncryption, data []byte) ([]byte, error) {
+	compact := strings.TrimSpace(string(data))
+	if strings.HasPrefix(compact, "{") {
+		var err error
+		if compact, err = jwe.CompactSerialize(); err != nil {
+			return nil, fmt.Errorf("converting clevis config for decryption: %w", err)
+		}
+	}
+	cmd := exec.Command(distro.ClevisCmd(), "decrypt")
+	cmd.Stdin = strings.NewReader(compact)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	plaintext, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("decrypting config with clevis: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return plaintext, nil
+}
+
+// loadKeys returns the private keys from the system config dir, in
+// lexical order of their files, followed by those in the key credential.
+func loadKeys(logger *log.Logger) ([]jose.JSONWebKey, error) {
+	dir := filepath.Join(distro.SystemConfigDir(), keysDirName)
+	paths, err := filepath.Glob(filepath.Join(dir, "*.jwk"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	for _, credDir := range distro.CredentialDirs() {
+		paths = append(paths, filepath.Join(credDir, keyCredential))
+	}
+
+	var keys []jose.JSONWebKey
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("reading key file %q: %w", path, err)
+		}
+		fileKeys, err := parseKeys(data)
+		if err != nil {
+			return nil, fmt.Errorf("parsing key file %q: %w", path, err)
+		}
+		for _, key := range fileKeys {
+			if key.IsPublic() {
+				logger.Warning("ignoring public key %q in %q", key.KeyID, path)
+				continue
+			}
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// parseKeys parses a JWK or a JWK set.
+func parseKeys(data []byte) ([]jose.JSONWebKey, error) {
+	var set jose.JSONWebKeySet
+	if err := json.Unmarshal(data, &set); err == nil && len(set.Keys) > 0 {
+		return set.Keys, nil
+	}
+	var key jose.JSONWebKey
+	if err := json.Unmarshal(data, &key); err != nil {
+		return nil, err
+	}
+	return []jose.JSONWebKey{key}, nil
+}
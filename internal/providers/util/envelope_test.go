@@ -0,0 +1,148 @@
+// Copyright 2026 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/coreos/ignition/v2/internal/log"
+	"github.com/coreos/ignition/v2/internal/providers/util"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encrypt(t *testing.T, plaintext string, alg jose.KeyAlgorithm, key interface{}, kid string, compact bool) []byte {
+	t.Helper()
+	enc, err := jose.NewEncrypter(jose.A256GCM, jose.Recipient{Algorithm: alg, Key: key, KeyID: kid}, nil)
+	require.NoError(t, err)
+	jwe, err := enc.Encrypt([]byte(plaintext))
+	require.NoError(t, err)
+	if compact {
+		out, err := jwe.CompactSerialize()
+		require.NoError(t, err)
+		return []byte(out)
+	}
+	return []byte(jwe.FullSerialize())
+}
+
+func writeJSON(t *testing.T, path string, v interface{}) {
+	t.Helper()
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, data, 0600))
+}
+
+func TestDecryptConfig(t *testing.T) {
+	config := `{"ignition":{"version":"3.0.0"}}`
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	secret := []byte("0123456789abcdef0123456789abcdef")
+
+	// keys from the initramfs, one file per key or a set
+	systemDir := t.TempDir()
+	t.Setenv("IGNITION_SYSTEM_CONFIG_DIR", systemDir)
+	keysDir := filepath.Join(systemDir, "config-keys.d")
+	writeJSON(t, filepath.Join(keysDir, "10-rsa.jwk"), jose.JSONWebKey{Key: rsaKey, KeyID: "rsa"})
+	writeJSON(t, filepath.Join(keysDir, "20-set.jwk"), jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+		{Key: ecKey, KeyID: "ec"},
+		// public keys can't decrypt anything
+		{Key: &otherKey.PublicKey, KeyID: "public"},
+	}})
+	// ignored, since it isn't a .jwk file
+	require.NoError(t, os.WriteFile(filepath.Join(keysDir, "README"), []byte("keys"), 0644))
+
+	// a key passed by the platform as a credential
+	credDir := t.TempDir()
+	t.Setenv("CREDENTIALS_DIRECTORY", credDir)
+	writeJSON(t, filepath.Join(credDir, "ignition.config.key"), jose.JSONWebKey{Key: secret, KeyID: "platform"})
+
+	tests := []struct {
+		in  []byte
+		out string
+		err error
+	}{
+		{
+			// plain configs are untouched
+			in:  []byte(config),
+			out: config,
+		},
+		{
+			in:  []byte("not a JWE.a.b.c.d"),
+			out: "not a JWE.a.b.c.d",
+		},
+		{
+			in:  encrypt(t, config, jose.RSA_OAEP_256, &rsaKey.PublicKey, "rsa", true),
+			out: config,
+		},
+		{
+			in:  encrypt(t, config, jose.ECDH_ES_A256KW, &ecKey.PublicKey, "ec", false),
+			out: config,
+		},
+		{
+			// without a kid, every key is tried
+			in:  encrypt(t, config, jose.ECDH_ES, &ecKey.PublicKey, "", true),
+			out: config,
+		},
+		{
+			in:  encrypt(t, config, jose.DIRECT, secret, "platform", true),
+			out: config,
+		},
+		{
+			in:  encrypt(t, config, jose.A256KW, secret, "", false),
+			out: config,
+		},
+		{
+			in:  encrypt(t, config, jose.ECDH_ES, &otherKey.PublicKey, "public", true),
+			err: util.ErrNoDecryptionKey,
+		},
+		{
+			// the kid must match
+			in:  encrypt(t, config, jose.RSA_OAEP_256, &rsaKey.PublicKey, "ec", true),
+			err: util.ErrNoDecryptionKey,
+		},
+	}
+
+	logger := log.New(true)
+	for i, test := range tests {
+		out, err := util.DecryptConfig(&logger, test.in)
+		if test.err != nil {
+			assert.Equal(t, test.err, err, "#%d: bad error", i)
+			continue
+		}
+		if assert.NoError(t, err, "#%d: unexpected error", i) {
+			assert.Equal(t, test.out, string(out), "#%d: bad output", i)
+		}
+	}
+
+	// end to end, the decrypted config is parsed
+	cfg, _, err := util.ParseConfig(&logger, encrypt(t, `{"ignition":{"version":"3.0.0"},"passwd":{"users":[{"name":"core"}]}}`, jose.ECDH_ES, &ecKey.PublicKey, "ec", true))
+	assert.NoError(t, err)
+	assert.Equal(t, "core", cfg.Passwd.Users[0].Name)
+}
@@ -0,0 +1,164 @@
+// Copyright 2026 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"strings"
+
+	"github.com/coreos/ignition/v2/internal/log"
+)
+
+const (
+	ignitionMediaType = "application/vnd.coreos.ignition+json"
+)
+
+var (
+	ErrMultipleIgnitionParts = errors.New("user data has more than one Ignition part")
+)
+
+// DecodeUserdata unwraps user data that may be gzipped, or a multipart MIME
+// document with parts for several consumers such as cloud-init. It returns
+// the part with the Ignition media type, or nil if a multipart document has
+// none. Other user data is returned as is.
+func DecodeUserdata(logger *log.Logger, raw []byte) ([]byte, error) {
+	data, err := TryUnzip(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	header, body, ok := readMIMEHeader(data)
+	if !ok {
+		return data, nil
+	}
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil || !strings.HasPrefix(mediaType, "multipart/") {
+		return data, nil
+	}
+
+	logger.Debug("unwrapping multipart user data")
+	part, err := findIgnitionPart(body, params["boundary"])
+	if err != nil {
+		return nil, err
+	}
+	if part == nil {
+		logger.Info("multipart user data has no Ignition part")
+	}
+	return part, nil
+}
+
+// readMIMEHeader returns the header and body of data if it starts with a
+// MIME header.
+func readMIMEHeader(data []byte) (textproto.MIMEHeader, []byte, bool) {
+	trimmed := bytes.TrimLeft(data, " \t\r\n")
+	if !hasPrefixFold(trimmed, "Content-Type:") && !hasPrefixFold(trimmed, "MIME-Version:") {
+		return nil, nil, false
+	}
+	reader := bufio.NewReader(bytes.NewReader(trimmed))
+	header, err := textproto.NewReader(reader).ReadMIMEHeader()
+	if err != nil {
+		return nil, nil, false
+	}
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, nil, false
+	}
+	return header, body, true
+}
+
+func hasPrefixFold(data []byte, prefix string) bool {
+	return len(data) >= len(prefix) && strings.EqualFold(string(data[:len(prefix)]), prefix)
+}
+
+// findIgnitionPart returns the decoded body of the Ignition part of a
+// multipart body, descending into nested multipart parts.
+func findIgnitionPart(body []byte, boundary string) ([]byte, error) {
+	if boundary == "" {
+		return nil, fmt.Errorf("multipart user data has no boundary")
+	}
+
+	var result []byte
+	reader := multipart.NewReader(bytes.NewReader(body), boundary)
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("reading multipart user data: %w", err)
+		}
+
+		// only decode the parts which may hold the config, since other
+		// consumers' parts can use encodings we don't support
+		mediaType, params, err := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		if err != nil {
+			continue
+		}
+		isMultipart := strings.HasPrefix(mediaType, "multipart/")
+		if mediaType != ignitionMediaType && !isMultipart {
+			continue
+		}
+		contents, err := decodePart(part)
+		if err != nil {
+			return nil, err
+		}
+
+		var found []byte
+		if isMultipart {
+			found, err = findIgnitionPart(contents, params["boundary"])
+		} else {
+			found, err = TryUnzip(contents)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if found != nil && result != nil {
+			return nil, ErrMultipleIgnitionParts
+		}
+		if found != nil {
+			result = found
+		}
+	}
+	return result, nil
+}
+
+// decodePart returns the body of a part, undoing its transfer encoding. The
+// multipart reader already decodes quoted-printable parts.
+func decodePart(part *multipart.Part) ([]byte, error) {
+	contents, err := io.ReadAll(part)
+	if err != nil {
+		return nil, fmt.Errorf("reading multipart user data: %w", err)
+	}
+
+	switch encoding := strings.ToLower(part.Header.Get("Content-Transfer-Encoding")); encoding {
+	case "", "7bit", "8bit", "binary":
+		return contents, nil
+	case "base64":
+		decoded, err := io.ReadAll(base64.NewDecoder(base64.StdEncoding, bytes.NewReader(contents)))
+		if err != nil {
+			return nil, fmt.Errorf("decoding multipart user data: %w", err)
+		}
+		return decoded, nil
+	default:
+		return nil, fmt.Errorf("unsupported transfer encoding %q in multipart user data", encoding)
+	}
+}
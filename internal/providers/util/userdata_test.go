@@ -0,0 +1,128 @@
+// Copyright 2026 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/coreos/ignition/v2/internal/log"
+	"github.com/coreos/ignition/v2/internal/providers/util"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeUserdata(t *testing.T) {
+	config := `{"ignition":{"version":"3.0.0"}}`
+	var gzipped bytes.Buffer
+	w := gzip.NewWriter(&gzipped)
+	_, _ = w.Write([]byte(config))
+	_ = w.Close()
+
+	multipart := func(parts ...string) string {
+		return "Content-Type: multipart/mixed; boundary=\"BOUNDARY\"\r\nMIME-Version: 1.0\r\n\r\n" +
+			"--BOUNDARY\r\n" + strings.Join(parts, "\r\n--BOUNDARY\r\n") + "\r\n--BOUNDARY--\r\n"
+	}
+	cloudConfig := "Content-Type: text/cloud-config\r\n\r\n#cloud-config\r\nruncmd: [reboot]"
+	ignition := "Content-Type: application/vnd.coreos.ignition+json\r\n\r\n" + config
+
+	tests := []struct {
+		name string
+		in   string
+		out  []byte
+		err  bool
+	}{
+		{
+			name: "plain",
+			in:   config,
+			out:  []byte(config),
+		},
+		{
+			name: "gzipped",
+			in:   gzipped.String(),
+			out:  []byte(config),
+		},
+		{
+			name: "multipart",
+			in:   multipart(cloudConfig, ignition),
+			out:  []byte(config),
+		},
+		{
+			name: "base64 part",
+			in: multipart(cloudConfig, "Content-Type: application/vnd.coreos.ignition+json; charset=\"utf-8\"\r\n"+
+				"Content-Transfer-Encoding: base64\r\n\r\n"+base64.StdEncoding.EncodeToString([]byte(config))),
+			out: []byte(config),
+		},
+		{
+			name: "gzipped part",
+			in: multipart("Content-Type: application/vnd.coreos.ignition+json\r\n" +
+				"Content-Transfer-Encoding: base64\r\n\r\n" + base64.StdEncoding.EncodeToString(gzipped.Bytes())),
+			out: []byte(config),
+		},
+		{
+			name: "quoted-printable part",
+			in: multipart("Content-Type: application/vnd.coreos.ignition+json\r\n" +
+				"Content-Transfer-Encoding: quoted-printable\r\n\r\n{\"ignition\":{\"version\":=\r\n\"3.0.0\"}}"),
+			out: []byte(config),
+		},
+		{
+			name: "nested",
+			in: multipart(cloudConfig, "Content-Type: multipart/alternative; boundary=INNER\r\n\r\n"+
+				"--INNER\r\n"+ignition+"\r\n--INNER--"),
+			out: []byte(config),
+		},
+		{
+			name: "no ignition part",
+			in:   multipart(cloudConfig),
+			out:  nil,
+		},
+		{
+			name: "multiple ignition parts",
+			in:   multipart(ignition, ignition),
+			err:  true,
+		},
+		{
+			name: "bad transfer encoding",
+			in:   multipart("Content-Type: application/vnd.coreos.ignition+json\r\nContent-Transfer-Encoding: uuencode\r\n\r\n" + config),
+			err:  true,
+		},
+		{
+			name: "other part with bad transfer encoding",
+			in:   multipart("Content-Type: text/x-shellscript\r\nContent-Transfer-Encoding: uuencode\r\n\r\nbegin 644 x", ignition),
+			out:  []byte(config),
+		},
+		{
+			name: "not multipart",
+			in:   "Content-Type: text/plain\r\n\r\nhello",
+			out:  []byte("Content-Type: text/plain\r\n\r\nhello"),
+		},
+	}
+
+	logger := log.New(true)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			out, err := util.DecodeUserdata(&logger, []byte(test.in))
+			if test.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.out, out)
+		})
+	}
+}
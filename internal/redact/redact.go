@@ -0,0 +1,161 @@
+// Copyright 2026 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package redact finds the secrets in a config, so they can be kept out of
+// logs and diagnostic output.
+package redact
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"slices"
+
+	"github.com/coreos/ignition/v2/config/util"
+	"github.com/coreos/ignition/v2/config/v3_7_experimental/types"
+	"github.com/coreos/ignition/v2/internal/log"
+
+	"github.com/mitchellh/copystructure"
+)
+
+// Placeholder replaces secrets in redacted configs.
+const Placeholder = "<redacted>"
+
+var (
+	// sensitiveHeaders are always treated as secrets, in canonical form
+	sensitiveHeaders = []string{"Authorization", "Cookie", "Proxy-Authorization"}
+
+	resourceType = reflect.TypeOf(types.Resource{})
+)
+
+// Config returns a copy of the config with its secrets replaced by
+// Placeholder. Secrets are the sources and headers of resources marked
+// sensitive, sensitive headers, passwords in URLs, password hashes, the
+// TLS client key, LUKS key files, and the S3 secret access key.
+func Config(cfg types.Config) (types.Config, error) {
+	// copy the config so its pointers and slices aren't shared
+	copied, err := copystructure.Copy(cfg)
+	if err != nil {
+		return types.Config{}, err
+	}
+	ret := copied.(types.Config)
+
+	r := redactor{replace: true}
+	r.config(&ret)
+	return ret, nil
+}
+
+// Secrets returns the values that Config would redact from the config.
+func Secrets(cfg types.Config) []string {
+	r := redactor{}
+	r.config(&cfg)
+	return r.secrets
+}
+
+type redactor struct {
+	secrets []string
+	// replace is false when only collecting the secrets, so the config
+	// isn't modified
+	replace bool
+}
+
+func (r *redactor) config(cfg *types.Config) {
+	// these resources are secret by nature
+	r.sensitiveResource(&cfg.Ignition.Security.TLS.ClientKey)
+	for i := range cfg.Storage.Luks {
+		r.sensitiveResource(&cfg.Storage.Luks[i].KeyFile)
+	}
+	r.walk(reflect.ValueOf(cfg).Elem())
+
+	r.secret(cfg.Ignition.S3.SecretAccessKey)
+	r.urlPassword(cfg.Ignition.Proxy.HTTPProxy)
+	r.urlPassword(cfg.Ignition.Proxy.HTTPSProxy)
+	for i := range cfg.Passwd.Users {
+		r.secret(cfg.Passwd.Users[i].PasswordHash)
+	}
+}
+
+// walk redacts every resource in v.
+func (r *redactor) walk(v reflect.Value) {
+	if v.Type() == resourceType {
+		r.resource(v.Addr().Interface().(*types.Resource))
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			r.walk(v.Field(i))
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			r.walk(v.Index(i))
+		}
+	case reflect.Ptr:
+		if !v.IsNil() {
+			r.walk(v.Elem())
+		}
+	}
+}
+
+func (r *redactor) resource(res *types.Resource) {
+	if util.IsTrue(res.Sensitive) {
+		r.sensitiveResource(res)
+		return
+	}
+	r.urlPassword(res.Source)
+	for i, header := range res.HTTPHeaders {
+		if util.IsTrue(header.Sensitive) || slices.Contains(sensitiveHeaders, http.CanonicalHeaderKey(header.Name)) {
+			r.secret(res.HTTPHeaders[i].Value)
+		}
+	}
+}
+
+func (r *redactor) sensitiveResource(res *types.Resource) {
+	r.secret(res.Source)
+	for i := range res.HTTPHeaders {
+		r.secret(res.HTTPHeaders[i].Value)
+	}
+}
+
+func (r *redactor) secret(s *string) {
+	if s == nil || *s == "" || *s == Placeholder {
+		return
+	}
+	r.secrets = append(r.secrets, *s)
+	if r.replace {
+		*s = Placeholder
+	}
+}
+
+// urlPassword redacts the password of a URL, leaving the rest readable.
+func (r *redactor) urlPassword(s *string) {
+	if s == nil {
+		return
+	}
+	u, err := url.Parse(*s)
+	if err != nil || u.User == nil {
+		return
+	}
+	if password, ok := u.User.Password(); ok && password != "" {
+		if len(password) < log.MinSecretLength {
+			// too short to redact on its own, so redact it along
+			// with the username, as it appears in logged URLs
+			r.secrets = append(r.secrets, u.User.String())
+		} else {
+			r.secrets = append(r.secrets, password)
+		}
+		if r.replace {
+			*s = u.Redacted()
+		}
+	}
+}
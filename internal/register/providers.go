@@ -25,14 +25,18 @@ import (
 	_ "github.com/coreos/ignition/v2/internal/providers/digitalocean"
 	_ "github.com/coreos/ignition/v2/internal/providers/exoscale"
 	_ "github.com/coreos/ignition/v2/internal/providers/file"
+	_ "github.com/coreos/ignition/v2/internal/providers/firecracker"
 	_ "github.com/coreos/ignition/v2/internal/providers/gcp"
 	_ "github.com/coreos/ignition/v2/internal/providers/hetzner"
 	_ "github.com/coreos/ignition/v2/internal/providers/hyperv"
 	_ "github.com/coreos/ignition/v2/internal/providers/ibmcloud"
+	_ "github.com/coreos/ignition/v2/internal/providers/incus"
 	_ "github.com/coreos/ignition/v2/internal/providers/kubevirt"
 	_ "github.com/coreos/ignition/v2/internal/providers/metal"
+	_ "github.com/coreos/ignition/v2/internal/providers/nocloud"
 	_ "github.com/coreos/ignition/v2/internal/providers/nutanix"
 	_ "github.com/coreos/ignition/v2/internal/providers/nvidiabluefield"
+	_ "github.com/coreos/ignition/v2/internal/providers/opennebula"
 	_ "github.com/coreos/ignition/v2/internal/providers/openstack"
 	_ "github.com/coreos/ignition/v2/internal/providers/oraclecloud"
 	_ "github.com/coreos/ignition/v2/internal/providers/packet"
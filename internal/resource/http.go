@@ -15,22 +15,30 @@
 package resource
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/base64"
 	"encoding/hex"
 	"encoding/pem"
 	"errors"
+	"fmt"
 	"io"
+	"io/fs"
 	"net"
 	"net/http"
 	"net/url"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
 	ignerrors "github.com/coreos/ignition/v2/config/shared/errors"
 	"github.com/coreos/ignition/v2/config/v3_7_experimental/types"
+	"github.com/coreos/ignition/v2/internal/distro"
 	"github.com/coreos/ignition/v2/internal/earlyrand"
 	"github.com/coreos/ignition/v2/internal/log"
 	"github.com/coreos/ignition/v2/internal/util"
@@ -45,13 +53,24 @@ const (
 	initialBackoff = 200 * time.Millisecond
 	maxBackoff     = 5 * time.Second
 
+	// maxResumeAttempts is the number of times in a row reading a response
+	// body may fail without making progress before giving up
+	maxResumeAttempts = 5
+
 	defaultHttpResponseHeaderTimeout = 10
 	defaultHttpTotalTimeout          = 0
+
+	// clientKeyCredential is the systemd credential holding the TLS client
+	// key if the config doesn't specify one
+	clientKeyCredential = "ignition.tls.client-key"
 )
 
 var (
 	ErrTimeout         = errors.New("unable to fetch resource in time")
 	ErrPEMDecodeFailed = errors.New("unable to decode PEM block")
+	ErrResumeFailed    = errors.New("server returned an unexpected range when resuming the fetch")
+	ErrResourceChanged = errors.New("resource changed while it was being fetched")
+	ErrPinMismatch     = errors.New("server certificate chain doesn't match any pinned public key")
 )
 
 // HttpClient is a simple wrapper around the Go HTTP client that standardizes
@@ -63,9 +82,19 @@ type HttpClient struct {
 
 	transport *http.Transport
 	cas       map[string][]byte
+
+	// defaultTLS holds the TLS settings the client was created with, on
+	// which the settings from each config are built
+	defaultTLS *tls.Config
+
+	// retry holds the retry settings from ignition.timeouts, which apply
+	// to fetches over every scheme that supports retries
+	retry types.Retry
 }
 
-func (f *Fetcher) UpdateHttpTimeoutsAndCAs(timeouts types.Timeouts, cas []types.Resource, proxy types.Proxy) error {
+// UpdateHttpTimeoutsAndCAs replaces the settings of the fetcher's HTTP client
+// with the given timeouts, proxy, CAs, and TLS client certificate.
+func (f *Fetcher) UpdateHttpTimeoutsAndCAs(timeouts types.Timeouts, tlsConfig types.TLS, proxy types.Proxy) error {
 	if f.client == nil {
 		if err := f.newHttpClient(); err != nil {
 			return err
@@ -88,34 +117,55 @@ func (f *Fetcher) UpdateHttpTimeoutsAndCAs(timeouts types.Timeouts, cas []types.
 	f.client.transport.ResponseHeaderTimeout = time.Duration(responseHeader) * time.Second
 	f.client.client.Transport = f.client.transport
 
+	// Update retry policy
+	f.client.retry = timeouts.Retry
+
 	// Update proxy
 	f.client.transport.Proxy = func(req *http.Request) (*url.URL, error) {
 		return proxyFuncFromIgnitionConfig(proxy)(req.URL)
 	}
 	f.client.client.Transport = f.client.transport
 
-	// Update CAs
-	if len(cas) == 0 {
-		return nil
+	// Update CAs, client certificate, and pins. The TLS settings are
+	// rebuilt from scratch, so nothing carries over from a previous config.
+	clientConfig := &tls.Config{}
+	if f.client.defaultTLS != nil {
+		clientConfig = f.client.defaultTLS.Clone()
 	}
 
-	pool, err := x509.SystemCertPool()
-	if err != nil {
-		f.Logger.Err("Unable to read system certificate pool: %s", err)
-		return err
-	}
-
-	for _, ca := range cas {
-		cablob, err := f.getCABlob(ca)
+	cas := tlsConfig.CertificateAuthorities
+	if len(cas) > 0 {
+		pool, err := x509.SystemCertPool()
 		if err != nil {
+			f.Logger.Err("Unable to read system certificate pool: %s", err)
 			return err
 		}
-		if err := f.parseCABundle(cablob, ca, pool); err != nil {
-			f.Logger.Err("Unable to parse CA bundle: %s", err)
+
+		for _, ca := range cas {
+			cablob, err := f.getCABlob(ca)
+			if err != nil {
+				return err
+			}
+			if err := f.parseCABundle(cablob, ca, pool); err != nil {
+				f.Logger.Err("Unable to parse CA bundle: %s", err)
+				return err
+			}
+		}
+		clientConfig.RootCAs = pool
+	}
+
+	if tlsConfig.ClientCertificate.Source != nil {
+		cert, err := f.getClientCertificate(tlsConfig.ClientCertificate, tlsConfig.ClientKey)
+		if err != nil {
 			return err
 		}
+		clientConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if len(tlsConfig.Pins) > 0 {
+		clientConfig.VerifyConnection = verifyPins(tlsConfig.Pins)
 	}
-	f.client.transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	f.client.transport.TLSClientConfig = clientConfig
 	return nil
 }
 
@@ -140,20 +190,131 @@ func (f *Fetcher) parseCABundle(cablob []byte, ca types.Resource, pool *x509.Cer
 }
 
 func (f *Fetcher) getCABlob(ca types.Resource) ([]byte, error) {
+	return f.getTLSBlob(ca, "CA", false)
+}
+
+// verifyPins returns a VerifyConnection callback which requires the verified
+// chain to contain a certificate whose SubjectPublicKeyInfo hashes to one of
+// the pins applying to the server. It runs after normal chain validation, so
+// pinning can only further restrict the set of trusted servers. Servers with
+// no applicable pins are not restricted.
+func verifyPins(pins []types.TLSPin) func(tls.ConnectionState) error {
+	return func(cs tls.ConnectionState) error {
+		var sums [][]byte
+		for _, pin := range pins {
+			if pin.Host != nil && !pinAppliesTo(*pin.Host, cs) {
+				continue
+			}
+			sum, err := base64.StdEncoding.DecodeString(pin.SHA256)
+			if err != nil {
+				return fmt.Errorf("decoding pin: %w", err)
+			}
+			sums = append(sums, sum)
+		}
+		if len(sums) == 0 {
+			return nil
+		}
+		for _, chain := range cs.VerifiedChains {
+			for _, cert := range chain {
+				spki := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+				for _, sum := range sums {
+					if bytes.Equal(spki[:], sum) {
+						return nil
+					}
+				}
+			}
+		}
+		if cs.ServerName == "" {
+			return ErrPinMismatch
+		}
+		return fmt.Errorf("%s: %w", cs.ServerName, ErrPinMismatch)
+	}
+}
+
+// pinAppliesTo reports whether a pin for host applies to the connection.
+// No server name is sent for IP addresses, so those are matched against the
+// leaf certificate, which chain validation has already checked against the
+// address being dialed.
+func pinAppliesTo(host string, cs tls.ConnectionState) bool {
+	if cs.ServerName != "" {
+		return strings.EqualFold(host, cs.ServerName)
+	}
+	if net.ParseIP(host) == nil || len(cs.PeerCertificates) == 0 {
+		return false
+	}
+	return cs.PeerCertificates[0].VerifyHostname(host) == nil
+}
+
+// getClientCertificate fetches and parses a PEM-encoded TLS client
+// certificate chain and private key. The key's source is never logged, since
+// it may be a data URL containing the key itself.
+func (f *Fetcher) getClientCertificate(certificate, key types.Resource) (tls.Certificate, error) {
+	certblob, err := f.getTLSBlob(certificate, "client certificate", false)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	keyblob, err := f.getClientKeyBlob(key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	cert, err := tls.X509KeyPair(certblob, keyblob)
+	if err != nil {
+		f.Logger.Err("Unable to load client certificate (%v): %s", *certificate.Source, err)
+		return tls.Certificate{}, err
+	}
+	if cert.Leaf != nil {
+		f.Logger.Info("Using client certificate %q", cert.Leaf.Subject.CommonName)
+	}
+	return cert, nil
+}
+
+// getClientKeyBlob fetches the TLS client key. If the config doesn't specify
+// one, the key is read from the clientKeyCredential systemd credential, which
+// lets platforms pass it to the initramfs without embedding it in the config.
+func (f *Fetcher) getClientKeyBlob(key types.Resource) ([]byte, error) {
+	if key.Source != nil {
+		return f.getTLSBlob(key, "client key", true)
+	}
+	for _, dir := range distro.CredentialDirs() {
+		blob, err := os.ReadFile(filepath.Join(dir, clientKeyCredential))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			f.Logger.Err("Unable to read credential %q: %s", clientKeyCredential, err)
+			return nil, err
+		}
+		f.Logger.Info("Reading client key from credential %q in %q", clientKeyCredential, dir)
+		return blob, nil
+	}
+	f.Logger.Err("No client key specified and credential %q not found", clientKeyCredential)
+	return nil, ErrNotFound
+}
+
+// getTLSBlob fetches a TLS resource, caching it by source. If sensitive is
+// set, the source is redacted from log messages.
+func (f *Fetcher) getTLSBlob(res types.Resource, kind string, sensitive bool) ([]byte, error) {
 	// this is also already checked at validation time
-	if ca.Source == nil {
-		f.Logger.Crit("invalid CA: %v", ignerrors.ErrSourceRequired)
+	if res.Source == nil {
+		f.Logger.Crit("invalid %s: %v", kind, ignerrors.ErrSourceRequired)
 		return nil, ignerrors.ErrSourceRequired
 	}
-	if blob, ok := f.client.cas[*ca.Source]; ok {
+	if blob, ok := f.client.cas[*res.Source]; ok {
 		return blob, nil
 	}
-	u, err := url.Parse(*ca.Source)
+	source := *res.Source
+	if sensitive {
+		source = "<redacted>"
+	}
+	u, err := url.Parse(*res.Source)
 	if err != nil {
-		f.Logger.Crit("Unable to parse CA URL: %s", err)
+		if sensitive {
+			// the error includes the URL
+			err = ignerrors.ErrInvalidUrl
+		}
+		f.Logger.Crit("Unable to parse %s URL: %s", kind, err)
 		return nil, err
 	}
-	hasher, err := util.GetHasher(ca.Verification)
+	hasher, err := util.GetHasher(res.Verification)
 	if err != nil {
 		f.Logger.Crit("Unable to get hasher: %s", err)
 		return nil, err
@@ -163,7 +324,7 @@ func (f *Fetcher) getCABlob(ca types.Resource) ([]byte, error) {
 	if hasher != nil {
 		// explicitly ignoring the error here because the config should already
 		// be validated by this point
-		_, expectedSumString, _ := util.HashParts(ca.Verification)
+		_, expectedSumString, _ := util.HashParts(res.Verification)
 		expectedSum, err = hex.DecodeString(expectedSumString)
 		if err != nil {
 			f.Logger.Crit("Error parsing verification string %q: %v", expectedSumString, err)
@@ -172,31 +333,31 @@ func (f *Fetcher) getCABlob(ca types.Resource) ([]byte, error) {
 	}
 
 	var headers http.Header
-	if len(ca.HTTPHeaders) > 0 {
-		headers, err = ca.HTTPHeaders.Parse()
+	if len(res.HTTPHeaders) > 0 {
+		headers, err = res.HTTPHeaders.Parse()
 		if err != nil {
 			return nil, err
 		}
 	}
 
 	var compression string
-	if ca.Compression != nil {
-		compression = *ca.Compression
+	if res.Compression != nil {
+		compression = *res.Compression
 	}
 
-	cablob, err := f.FetchToBuffer(*u, FetchOptions{
+	blob, err := f.FetchToBuffer(*u, FetchOptions{
 		Hash:        hasher,
 		Headers:     headers,
 		ExpectedSum: expectedSum,
 		Compression: compression,
+		Retry:       res.Retry,
 	})
 	if err != nil {
-		f.Logger.Err("Unable to fetch CA (%s): %s", u, err)
+		f.Logger.Err("Unable to fetch %s (%s): %s", kind, source, err)
 		return nil, err
 	}
-	f.client.cas[*ca.Source] = cablob
-	return cablob, nil
-
+	f.client.cas[*res.Source] = blob
+	return blob, nil
 }
 
 // RewriteCAsWithDataUrls will modify the passed in slice of CA references to
@@ -207,18 +368,48 @@ func (f *Fetcher) RewriteCAsWithDataUrls(cas []types.Resource) error {
 		if err != nil {
 			return err
 		}
+		rewriteWithDataUrl(&cas[i], blob)
+	}
+	return nil
+}
 
-		// Clean HTTP headers
-		cas[i].HTTPHeaders = nil
-		// the rewrite wipes the compression
-		cas[i].Compression = nil
-
-		encoded := dataurl.EncodeBytes(blob)
-		cas[i].Source = &encoded
+// RewriteTLSWithDataUrls is like RewriteCAsWithDataUrls, but additionally
+// rewrites the TLS client certificate and key. A key read from a systemd
+// credential is left out of the config, since later stages can read the
+// credential again.
+func (f *Fetcher) RewriteTLSWithDataUrls(tlsConfig *types.TLS) error {
+	if err := f.RewriteCAsWithDataUrls(tlsConfig.CertificateAuthorities); err != nil {
+		return err
+	}
+	if tlsConfig.ClientCertificate.Source == nil {
+		return nil
+	}
+	certblob, err := f.getTLSBlob(tlsConfig.ClientCertificate, "client certificate", false)
+	if err != nil {
+		return err
 	}
+	rewriteWithDataUrl(&tlsConfig.ClientCertificate, certblob)
+	if tlsConfig.ClientKey.Source == nil {
+		return nil
+	}
+	keyblob, err := f.getTLSBlob(tlsConfig.ClientKey, "client key", true)
+	if err != nil {
+		return err
+	}
+	rewriteWithDataUrl(&tlsConfig.ClientKey, keyblob)
 	return nil
 }
 
+func rewriteWithDataUrl(res *types.Resource, blob []byte) {
+	// Clean HTTP headers
+	res.HTTPHeaders = nil
+	// the rewrite wipes the compression
+	res.Compression = nil
+
+	encoded := dataurl.EncodeBytes(blob)
+	res.Source = &encoded
+}
+
 func isFIPSEnabled() bool {
 	data, err := os.ReadFile("/proc/sys/crypto/fips_enabled")
 	if err != nil {
@@ -283,6 +474,13 @@ func defaultHTTPClient() (*http.Client, error) {
 	return &client, nil
 }
 
+// ResetHttpClient discards the fetcher's HTTP client, along with the
+// timeouts, proxy, and TLS settings of any config and the connections made
+// with them, so the next fetch uses a new client with the defaults.
+func (f *Fetcher) ResetHttpClient() {
+	f.client = nil
+}
+
 // newHttpClient populates the fetcher with the default HTTP client.
 func (f *Fetcher) newHttpClient() error {
 	defaultClient, err := defaultHTTPClient()
@@ -291,11 +489,12 @@ func (f *Fetcher) newHttpClient() error {
 	}
 
 	f.client = &HttpClient{
-		client:    defaultClient,
-		logger:    f.Logger,
-		timeout:   time.Duration(defaultHttpTotalTimeout) * time.Second,
-		transport: defaultClient.Transport.(*http.Transport),
-		cas:       make(map[string][]byte),
+		client:     defaultClient,
+		logger:     f.Logger,
+		timeout:    time.Duration(defaultHttpTotalTimeout) * time.Second,
+		transport:  defaultClient.Transport.(*http.Transport),
+		cas:        make(map[string][]byte),
+		defaultTLS: defaultClient.Transport.(*http.Transport).TLSClientConfig.Clone(),
 	}
 	return nil
 }
@@ -319,13 +518,25 @@ func shouldRetryHttp(statusCode int, opts FetchOptions) bool {
 // provided request header & method and returns the response body Reader, HTTP
 // status code, a cancel function for the result's context, and error (if any).
 // By default, User-Agent is added to the header but this can be overridden.
+// If reading the body of a successful GET fails, the remainder of the
+// resource is requested again (see resumableBody).
 func (c HttpClient) httpReaderWithHeader(opts FetchOptions, url string) (io.ReadCloser, int, context.CancelFunc, error) {
+	resp, cancelFn, err := c.httpResponseWithHeader(opts, url)
+	if err != nil {
+		return nil, 0, cancelFn, err
+	}
+	return c.newResumableBody(resp, opts, url), resp.StatusCode, cancelFn, nil
+}
+
+// httpResponseWithHeader is like httpReaderWithHeader, but returns the whole
+// response for callers that need to inspect the response headers.
+func (c HttpClient) httpResponseWithHeader(opts FetchOptions, url string) (*http.Response, context.CancelFunc, error) {
 	if opts.HTTPVerb == "" {
 		opts.HTTPVerb = "GET"
 	}
 	req, err := http.NewRequest(opts.HTTPVerb, url, nil)
 	if err != nil {
-		return nil, 0, nil, err
+		return nil, nil, err
 	}
 
 	req.Header.Set("User-Agent", "Ignition/"+version.Raw)
@@ -343,7 +554,7 @@ func (c HttpClient) httpReaderWithHeader(opts FetchOptions, url string) (io.Read
 		ctx, cancelFn = context.WithTimeout(context.Background(), c.timeout)
 	}
 
-	duration := initialBackoff
+	policy := newRetryPolicy(c.retry, opts.Retry)
 	for attempt := 1; ; attempt++ {
 		c.logger.Info("%s %s: attempt #%d", opts.HTTPVerb, url, attempt)
 		resp, err := c.client.Do(req.WithContext(ctx))
@@ -351,25 +562,198 @@ func (c HttpClient) httpReaderWithHeader(opts FetchOptions, url string) (io.Read
 		if err == nil {
 			c.logger.Info("%s result: %s", opts.HTTPVerb, http.StatusText(resp.StatusCode))
 			if !shouldRetryHttp(resp.StatusCode, opts) {
-				return resp.Body, resp.StatusCode, cancelFn, nil
+				return resp, cancelFn, nil
+			}
+			if policy.exhausted(attempt) {
+				c.logger.Info("%s %s: giving up after %d attempts", opts.HTTPVerb, url, attempt)
+				return resp, cancelFn, nil
 			}
 			_ = resp.Body.Close()
 		} else {
 			c.logger.Info("%s error: %v", opts.HTTPVerb, err)
+			if policy.exhausted(attempt) {
+				c.logger.Info("%s %s: giving up after %d attempts", opts.HTTPVerb, url, attempt)
+				return nil, cancelFn, err
+			}
 		}
 
 		// Wait before next attempt or exit if we timeout while waiting
 		select {
-		case <-time.After(duration):
+		case <-time.After(policy.backoff(attempt)):
 		case <-ctx.Done():
-			return nil, 0, cancelFn, ErrTimeout
+			return nil, cancelFn, ErrTimeout
+		}
+	}
+}
+
+// resumableBody wraps the body of a successful GET response. If reading the
+// body fails partway through, the rest of the resource is requested again.
+// When the server supports byte ranges, the request resumes at the current
+// offset using Range and If-Range; otherwise the whole resource is fetched
+// again and the bytes already read are skipped. Either way, the reader sees
+// one continuous stream, so decompression and hashing of the data are
+// unaffected by the resumption.
+type resumableBody struct {
+	client HttpClient
+	opts   FetchOptions
+	url    string
+
+	body     io.ReadCloser
+	cancelFn context.CancelFunc
+
+	// offset is the number of bytes read so far
+	offset int64
+	// length is the Content-Length of the original response, or -1
+	length int64
+	// validator is the strong ETag or Last-Modified date of the original
+	// response, used to detect that the resource changed between requests
+	validator string
+	// ranges is whether the server accepts byte range requests
+	ranges bool
+	// deadline is when the total timeout of the original request expires,
+	// which also bounds any resumed requests
+	deadline time.Time
+}
+
+// newResumableBody returns the body of resp, wrapped in a resumableBody if
+// the request can be safely repeated and the resource has a Content-Length or
+// validator which can be used to detect that it changed.
+func (c HttpClient) newResumableBody(resp *http.Response, opts FetchOptions, url string) io.ReadCloser {
+	if (opts.HTTPVerb != "" && opts.HTTPVerb != "GET") || resp.StatusCode != http.StatusOK || resp.Uncompressed {
+		return resp.Body
+	}
+	validator := responseValidator(resp)
+	if validator == "" && resp.ContentLength < 0 {
+		return resp.Body
+	}
+	var deadline time.Time
+	if c.timeout != 0 {
+		deadline = time.Now().Add(c.timeout)
+	}
+	return &resumableBody{
+		client:    c,
+		deadline:  deadline,
+		opts:      opts,
+		url:       url,
+		body:      resp.Body,
+		length:    resp.ContentLength,
+		validator: validator,
+		ranges:    resp.Header.Get("Accept-Ranges") == "bytes",
+	}
+}
+
+// responseValidator returns a validator suitable for If-Range: the ETag if it
+// is strong, otherwise the Last-Modified date.
+func responseValidator(resp *http.Response) string {
+	if etag := resp.Header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		return etag
+	}
+	return resp.Header.Get("Last-Modified")
+}
+
+func (r *resumableBody) Read(p []byte) (int, error) {
+	policy := newRetryPolicy(r.client.retry, r.opts.Retry)
+	for attempt := 1; ; attempt++ {
+		n, err := r.body.Read(p)
+		r.offset += int64(n)
+		if err == nil || err == io.EOF {
+			return n, err
+		}
+		if n > 0 {
+			// return the data we have; the error will recur on the
+			// next read
+			return n, nil
+		}
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+			return 0, ErrTimeout
+		}
+		if attempt > maxResumeAttempts || policy.exhausted(attempt) {
+			return 0, err
+		}
+		r.client.logger.Info("reading %s failed at offset %d: %v; resuming", r.url, r.offset, err)
+		time.Sleep(policy.backoff(attempt))
+
+		if err := r.resume(); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// resume replaces the failed body with the body of a new request, positioned
+// at the current offset.
+func (r *resumableBody) resume() error {
+	_ = r.body.Close()
+	r.body = http.NoBody
+	if r.cancelFn != nil {
+		r.cancelFn()
+		r.cancelFn = nil
+	}
+
+	opts := r.opts
+	opts.Headers = r.opts.Headers.Clone()
+	if opts.Headers == nil {
+		opts.Headers = make(http.Header)
+	}
+	if r.ranges && r.validator != "" {
+		opts.Headers.Set("Range", fmt.Sprintf("bytes=%d-", r.offset))
+		opts.Headers.Set("If-Range", r.validator)
+	}
+	client := r.client
+	if !r.deadline.IsZero() {
+		client.timeout = time.Until(r.deadline)
+		if client.timeout <= 0 {
+			return ErrTimeout
 		}
+	}
+	resp, cancelFn, err := client.httpResponseWithHeader(opts, r.url)
+	r.cancelFn = cancelFn
+	if err != nil {
+		return err
+	}
+	r.body = resp.Body
 
-		duration = duration * 2
-		if duration > maxBackoff {
-			duration = maxBackoff
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		start, err := contentRangeStart(resp.Header.Get("Content-Range"))
+		if err != nil || start != r.offset {
+			return ErrResumeFailed
 		}
+		r.client.logger.Info("resumed %s at offset %d", r.url, r.offset)
+	case http.StatusOK:
+		// the server ignored the range or the resource changed
+		if responseValidator(resp) != r.validator || resp.ContentLength != r.length {
+			return ErrResourceChanged
+		}
+		if _, err := io.CopyN(io.Discard, resp.Body, r.offset); err != nil {
+			return err
+		}
+		r.client.logger.Info("restarted %s and skipped %d bytes", r.url, r.offset)
+	default:
+		return ErrFailed
+	}
+	return nil
+}
+
+func (r *resumableBody) Close() error {
+	err := r.body.Close()
+	if r.cancelFn != nil {
+		r.cancelFn()
+	}
+	return err
+}
+
+// contentRangeStart returns the first byte position of a Content-Range
+// header of the form "bytes <start>-<end>/<length>".
+func contentRangeStart(header string) (int64, error) {
+	spec, ok := strings.CutPrefix(header, "bytes ")
+	if !ok {
+		return 0, ErrResumeFailed
+	}
+	start, _, ok := strings.Cut(spec, "-")
+	if !ok {
+		return 0, ErrResumeFailed
 	}
+	return strconv.ParseInt(start, 10, 64)
 }
 
 func proxyFuncFromIgnitionConfig(proxy types.Proxy) func(*url.URL) (*url.URL, error) {
@@ -0,0 +1,329 @@
+// Copyright 2026 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vincent-petithory/dataurl"
+
+	cutil "github.com/coreos/ignition/v2/config/util"
+	"github.com/coreos/ignition/v2/config/v3_7_experimental/types"
+	"github.com/coreos/ignition/v2/internal/log"
+)
+
+// flakyServer drops the connection halfway through the body of the first
+// response, and serves later requests normally.
+type flakyServer struct {
+	data   []byte
+	etags  []string
+	ranges bool
+
+	requests []http.Header
+}
+
+func (s *flakyServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.requests = append(s.requests, r.Header.Clone())
+	w.Header().Set("ETag", s.etags[min(len(s.requests), len(s.etags))-1])
+	if len(s.requests) == 1 {
+		w.Header().Set("Content-Length", strconv.Itoa(len(s.data)))
+		if s.ranges {
+			w.Header().Set("Accept-Ranges", "bytes")
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(s.data[:len(s.data)/2])
+		w.(http.Flusher).Flush()
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err == nil {
+			_ = conn.Close()
+		}
+		return
+	}
+	if !s.ranges {
+		w.Header().Set("Content-Length", strconv.Itoa(len(s.data)))
+		_, _ = w.Write(s.data)
+		return
+	}
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(s.data))
+}
+
+func TestFetchFromHTTPResume(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789abcdef"), 64*1024)
+	sum := sha512.Sum512(data)
+
+	tests := []struct {
+		server flakyServer
+		rng    string
+		err    error
+	}{
+		// resumed with a range request
+		{
+			server: flakyServer{etags: []string{`"v1"`}, ranges: true},
+			rng:    fmt.Sprintf("bytes=%d-", len(data)/2),
+		},
+		// restarted from the beginning
+		{
+			server: flakyServer{etags: []string{`"v1"`}},
+		},
+		// changed between requests
+		{
+			server: flakyServer{etags: []string{`"v1"`, `"v2"`}, ranges: true},
+			rng:    fmt.Sprintf("bytes=%d-", len(data)/2),
+			err:    ErrResourceChanged,
+		},
+		{
+			server: flakyServer{etags: []string{`"v1"`, `"v2"`}},
+			err:    ErrResourceChanged,
+		},
+	}
+
+	for i, test := range tests {
+		test.server.data = data
+		server := httptest.NewServer(&test.server)
+		logger := log.New(true)
+		f := Fetcher{
+			Logger: &logger,
+			client: &HttpClient{
+				client:    server.Client(),
+				logger:    &logger,
+				transport: server.Client().Transport.(*http.Transport),
+				cas:       make(map[string][]byte),
+			},
+		}
+		u, err := url.Parse(server.URL)
+		assert.NoError(t, err)
+		result, err := f.FetchToBuffer(*u, FetchOptions{
+			Hash:        sha512.New(),
+			ExpectedSum: sum[:],
+		})
+		server.Close()
+
+		assert.Equal(t, test.err, err, "#%d: bad error", i)
+		if test.err == nil {
+			assert.True(t, bytes.Equal(data, result), "#%d: bad data", i)
+		}
+		if assert.Len(t, test.server.requests, 2, "#%d: bad request count", i) {
+			retry := test.server.requests[1]
+			assert.Equal(t, test.rng, retry.Get("Range"), "#%d: bad Range", i)
+			if test.rng != "" {
+				assert.Equal(t, `"v1"`, retry.Get("If-Range"), "#%d: bad If-Range", i)
+			}
+		}
+	}
+}
+
+// newClientCertificate returns a self-signed PEM-encoded client certificate
+// and key.
+func newClientCertificate(t *testing.T) (*x509.Certificate, []byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "ignition-client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+	keyDer, err := x509.MarshalPKCS8PrivateKey(key)
+	assert.NoError(t, err)
+	return cert,
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDer})
+}
+
+func TestClientCertificate(t *testing.T) {
+	cert, certPEM, keyPEM := newClientCertificate(t)
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	server.TLS = &tls.Config{
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  pool,
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	credDir := t.TempDir()
+	t.Setenv("CREDENTIALS_DIRECTORY", credDir)
+	assert.NoError(t, os.WriteFile(filepath.Join(credDir, clientKeyCredential), keyPEM, 0600))
+
+	logger := log.New(true)
+	u, err := url.Parse(server.URL)
+	assert.NoError(t, err)
+	tests := []struct {
+		tls types.TLS
+		err bool
+	}{
+		{
+			tls: types.TLS{},
+			err: true,
+		},
+		{
+			tls: types.TLS{
+				ClientCertificate: types.Resource{Source: cutil.StrToPtr(dataurl.EncodeBytes(certPEM))},
+				ClientKey:         types.Resource{Source: cutil.StrToPtr(dataurl.EncodeBytes(keyPEM))},
+			},
+		},
+		// key from the systemd credential
+		{
+			tls: types.TLS{
+				ClientCertificate: types.Resource{Source: cutil.StrToPtr(dataurl.EncodeBytes(certPEM))},
+			},
+		},
+	}
+
+	for i, test := range tests {
+		f := Fetcher{
+			Logger: &logger,
+			client: newTestHttpClient(server, &logger),
+		}
+		timeout := 1
+		err := f.UpdateHttpTimeoutsAndCAs(types.Timeouts{HTTPTotal: &timeout}, test.tls, types.Proxy{})
+		assert.NoError(t, err, "#%d: updating fetcher", i)
+		data, err := f.FetchToBuffer(*u, FetchOptions{})
+		if test.err {
+			assert.Error(t, err, "#%d: expected error", i)
+		} else {
+			assert.NoError(t, err, "#%d: unexpected error", i)
+			assert.Equal(t, "ignition-client", string(data), "#%d: bad data", i)
+		}
+	}
+
+	// the key must match the certificate
+	_, _, otherKeyPEM := newClientCertificate(t)
+	f := Fetcher{Logger: &logger}
+	err = f.UpdateHttpTimeoutsAndCAs(types.Timeouts{}, types.TLS{
+		ClientCertificate: types.Resource{Source: cutil.StrToPtr(dataurl.EncodeBytes(certPEM))},
+		ClientKey:         types.Resource{Source: cutil.StrToPtr(dataurl.EncodeBytes(otherKeyPEM))},
+	}, types.Proxy{})
+	assert.Error(t, err)
+
+	// the credential must exist if the key is omitted
+	t.Setenv("CREDENTIALS_DIRECTORY", t.TempDir())
+	err = f.UpdateHttpTimeoutsAndCAs(types.Timeouts{}, types.TLS{
+		ClientCertificate: types.Resource{Source: cutil.StrToPtr(dataurl.EncodeBytes(certPEM))},
+	}, types.Proxy{})
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestPinnedPublicKey(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("pinned"))
+	}))
+	defer server.Close()
+
+	spki := sha256.Sum256(server.Certificate().RawSubjectPublicKeyInfo)
+	pin := base64.StdEncoding.EncodeToString(spki[:])
+	other := base64.StdEncoding.EncodeToString(make([]byte, sha256.Size))
+
+	logger := log.New(true)
+	u, err := url.Parse(server.URL)
+	assert.NoError(t, err)
+	tests := []struct {
+		pins []types.TLSPin
+		err  bool
+	}{
+		{
+			pins: []types.TLSPin{{SHA256: pin}},
+		},
+		{
+			pins: []types.TLSPin{{SHA256: other}, {Host: cutil.StrToPtr(u.Hostname()), SHA256: pin}},
+		},
+		{
+			pins: []types.TLSPin{{SHA256: other}},
+			err:  true,
+		},
+		{
+			pins: []types.TLSPin{{Host: cutil.StrToPtr(u.Hostname()), SHA256: other}},
+			err:  true,
+		},
+		// pins for other hosts don't apply
+		{
+			pins: []types.TLSPin{{Host: cutil.StrToPtr("example.com"), SHA256: other}},
+		},
+	}
+
+	for i, test := range tests {
+		f := Fetcher{
+			Logger: &logger,
+			client: newTestHttpClient(server, &logger),
+		}
+		timeout := 1
+		err := f.UpdateHttpTimeoutsAndCAs(types.Timeouts{HTTPTotal: &timeout}, types.TLS{Pins: test.pins}, types.Proxy{})
+		assert.NoError(t, err, "#%d: updating fetcher", i)
+		data, err := f.FetchToBuffer(*u, FetchOptions{})
+		if test.err {
+			assert.Error(t, err, "#%d: expected error", i)
+		} else {
+			assert.NoError(t, err, "#%d: unexpected error", i)
+			assert.Equal(t, "pinned", string(data), "#%d: bad data", i)
+		}
+	}
+
+	// pins from a previous config don't carry over
+	f := Fetcher{
+		Logger: &logger,
+		client: newTestHttpClient(server, &logger),
+	}
+	timeout := 1
+	assert.NoError(t, f.UpdateHttpTimeoutsAndCAs(types.Timeouts{HTTPTotal: &timeout}, types.TLS{Pins: []types.TLSPin{{SHA256: other}}}, types.Proxy{}))
+	_, err = f.FetchToBuffer(*u, FetchOptions{})
+	assert.Error(t, err)
+	assert.NoError(t, f.UpdateHttpTimeoutsAndCAs(types.Timeouts{HTTPTotal: &timeout}, types.TLS{}, types.Proxy{}))
+	data, err := f.FetchToBuffer(*u, FetchOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "pinned", string(data))
+}
+
+// newTestHttpClient returns an HttpClient which trusts the test server.
+func newTestHttpClient(server *httptest.Server, logger *log.Logger) *HttpClient {
+	transport := server.Client().Transport.(*http.Transport).Clone()
+	return &HttpClient{
+		client:     server.Client(),
+		logger:     logger,
+		transport:  transport,
+		cas:        make(map[string][]byte),
+		defaultTLS: transport.TLSClientConfig.Clone(),
+	}
+}
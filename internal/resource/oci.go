@@ -0,0 +1,496 @@
+// Copyright 2026 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"runtime"
+	"strings"
+
+	configErrors "github.com/coreos/ignition/v2/config/shared/errors"
+	"github.com/coreos/ignition/v2/config/v3_7_experimental/types"
+	"github.com/coreos/ignition/v2/internal/util"
+)
+
+const (
+	ociManifestMediaType        = "application/vnd.oci.image.manifest.v1+json"
+	ociIndexMediaType           = "application/vnd.oci.image.index.v1+json"
+	dockerManifestMediaType     = "application/vnd.docker.distribution.manifest.v2+json"
+	dockerManifestListMediaType = "application/vnd.docker.distribution.manifest.list.v2+json"
+)
+
+var (
+	ErrOCIUnsupportedManifest = errors.New("unsupported OCI manifest")
+	ErrOCIUnsupportedLayer    = errors.New("unsupported OCI layer media type")
+	ErrOCIAmbiguousArtifact   = errors.New("OCI artifact must have exactly one layer unless a path is given")
+)
+
+// ociReference is a parsed oci:// URL.
+type ociReference struct {
+	registry   string
+	repository string
+	// tag or digest
+	reference string
+	// optional path of a file inside the image layers
+	path string
+	// optional credentials used to request bearer tokens
+	user *url.Userinfo
+}
+
+type ociDescriptor struct {
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"digest"`
+	Size      int64  `json:"size"`
+	Platform  *struct {
+		Architecture string `json:"architecture"`
+		OS           string `json:"os"`
+	} `json:"platform,omitempty"`
+}
+
+// ociManifest covers both image manifests and image indexes.
+type ociManifest struct {
+	MediaType string          `json:"mediaType"`
+	Layers    []ociDescriptor `json:"layers"`
+	Manifests []ociDescriptor `json:"manifests"`
+}
+
+// ociClient speaks the OCI distribution API to a single repository,
+// remembering the bearer token obtained for it.
+type ociClient struct {
+	f *Fetcher
+	// client is a copy of the fetcher's client with the registry's
+	// redirect policy
+	client HttpClient
+	ref    ociReference
+	token  string
+	retry  types.Retry
+}
+
+// parseOCIURL parses an URL of the form
+// oci://<registry>/<repository>(:<tag>|@<digest>)[?path=<path>].
+func parseOCIURL(u url.URL) (ociReference, error) {
+	ref := ociReference{
+		registry: u.Host,
+		path:     u.Query().Get("path"),
+		user:     u.User,
+	}
+	name := strings.TrimPrefix(u.Path, "/")
+	if i := strings.LastIndex(name, "@"); i >= 0 {
+		ref.repository, ref.reference = name[:i], name[i+1:]
+	} else if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		ref.repository, ref.reference = name[:i], name[i+1:]
+	}
+	if ref.registry == "" || ref.repository == "" || ref.reference == "" {
+		return ociReference{}, configErrors.ErrInvalidOCIReference
+	}
+	return ref, nil
+}
+
+// digestHasher returns a hasher and the expected sum for an OCI digest of
+// the form <algorithm>:<hex>.
+func digestHasher(digest string) (hash.Hash, []byte, error) {
+	algorithm, encoded, ok := strings.Cut(digest, ":")
+	if !ok {
+		return nil, nil, configErrors.ErrHashMalformed
+	}
+	sum, err := hex.DecodeString(encoded)
+	if err != nil {
+		return nil, nil, configErrors.ErrHashMalformed
+	}
+	var hasher hash.Hash
+	switch algorithm {
+	case "sha256":
+		hasher = sha256.New()
+	case "sha512":
+		hasher = sha512.New()
+	default:
+		return nil, nil, configErrors.ErrHashUnrecognized
+	}
+	if len(sum) != hasher.Size() {
+		return nil, nil, configErrors.ErrHashWrongSize
+	}
+	return hasher, sum, nil
+}
+
+// digestVerifier wraps a reader and verifies the digest of everything read
+// through it once io.EOF is reached.
+type digestVerifier struct {
+	r        io.Reader
+	hasher   hash.Hash
+	expected []byte
+}
+
+func newDigestVerifier(r io.Reader, digest string) (*digestVerifier, error) {
+	hasher, sum, err := digestHasher(digest)
+	if err != nil {
+		return nil, err
+	}
+	return &digestVerifier{r: r, hasher: hasher, expected: sum}, nil
+}
+
+func (v *digestVerifier) Read(p []byte) (int, error) {
+	n, err := v.r.Read(p)
+	v.hasher.Write(p[:n])
+	if err == io.EOF {
+		if calculated := v.hasher.Sum(nil); !bytes.Equal(calculated, v.expected) {
+			return n, util.ErrHashMismatch{
+				Calculated: hex.EncodeToString(calculated),
+				Expected:   hex.EncodeToString(v.expected),
+			}
+		}
+	}
+	return n, err
+}
+
+// fetchFromOCI fetches an artifact or a file inside an image from an OCI
+// registry as described by u into dest. Manifests and blobs are verified
+// against their digests as they are downloaded.
+//
+// Without a path, the reference must point to an artifact with a single
+// layer, whose contents are fetched. With a path, the layers of the image
+// are searched from top to bottom for the file.
+func (f *Fetcher) fetchFromOCI(u url.URL, dest io.Writer, opts FetchOptions) error {
+	ref, err := parseOCIURL(u)
+	if err != nil {
+		return err
+	}
+	if f.client == nil {
+		if err := f.newHttpClient(); err != nil {
+			return err
+		}
+	}
+	// Copy the client, so the redirect policy only applies to requests
+	// to the registry
+	client := *f.client
+	httpClient := *f.client.client
+	httpClient.CheckRedirect = ociCheckRedirect
+	client.client = &httpClient
+
+	c := &ociClient{f: f, client: client, ref: ref, retry: opts.Retry}
+	manifest, err := c.resolveManifest(ref.reference)
+	if err != nil {
+		return err
+	}
+
+	if ref.path == "" {
+		if len(manifest.Layers) != 1 {
+			return ErrOCIAmbiguousArtifact
+		}
+		blob, cancel, err := c.blob(manifest.Layers[0])
+		if cancel != nil {
+			defer cancel()
+		}
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = blob.Close()
+		}()
+		return f.decompressCopyHashAndVerify(dest, blob, opts)
+	}
+
+	target := strings.TrimPrefix(path.Clean("/"+ref.path), "/")
+	for i := len(manifest.Layers) - 1; i >= 0; i-- {
+		contents, found, err := c.findInLayer(manifest.Layers[i], target)
+		if err != nil {
+			return err
+		}
+		if found {
+			if contents == nil {
+				// whited out
+				return ErrNotFound
+			}
+			return f.decompressCopyHashAndVerify(dest, bytes.NewReader(contents), opts)
+		}
+	}
+	return ErrNotFound
+}
+
+// ociCheckRedirect follows up to 10 redirects, like the default policy of
+// http.Client. Registries commonly redirect blob downloads to object
+// storage, so the registry token is dropped when the host changes.
+func ociCheckRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return errors.New("stopped after 10 redirects")
+	}
+	if req.URL.Host != via[0].URL.Host {
+		req.Header.Del("Authorization")
+	}
+	return nil
+}
+
+// resolveManifest fetches the manifest for reference, descending into the
+// entry matching the running architecture if it is an index.
+func (c *ociClient) resolveManifest(reference string) (ociManifest, error) {
+	accept := strings.Join([]string{ociManifestMediaType, ociIndexMediaType, dockerManifestMediaType, dockerManifestListMediaType}, ", ")
+	resp, cancel, err := c.get(fmt.Sprintf("/v2/%s/manifests/%s", c.ref.repository, reference), accept)
+	if cancel != nil {
+		defer cancel()
+	}
+	if err != nil {
+		return ociManifest{}, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	var body io.Reader = resp.Body
+	if strings.Contains(reference, ":") {
+		// pinned by digest
+		if body, err = newDigestVerifier(resp.Body, reference); err != nil {
+			return ociManifest{}, err
+		}
+	}
+	raw, err := io.ReadAll(body)
+	if err != nil {
+		return ociManifest{}, err
+	}
+	var manifest ociManifest
+	if err := json.Unmarshal(raw, &manifest); err != nil {
+		return ociManifest{}, fmt.Errorf("parsing OCI manifest: %w", err)
+	}
+	mediaType := manifest.MediaType
+	if mediaType == "" {
+		mediaType = strings.TrimSpace(strings.Split(resp.Header.Get("Content-Type"), ";")[0])
+	}
+
+	switch mediaType {
+	case ociManifestMediaType, dockerManifestMediaType:
+		return manifest, nil
+	case ociIndexMediaType, dockerManifestListMediaType:
+		for _, m := range manifest.Manifests {
+			if m.Platform == nil || (m.Platform.OS == "linux" && m.Platform.Architecture == runtime.GOARCH) {
+				return c.resolveManifest(m.Digest)
+			}
+		}
+		return ociManifest{}, fmt.Errorf("no manifest for linux/%s in OCI index", runtime.GOARCH)
+	default:
+		return ociManifest{}, fmt.Errorf("%w: %q", ErrOCIUnsupportedManifest, mediaType)
+	}
+}
+
+// blob returns a reader for the blob described by desc, which verifies the
+// digest of the blob when it is read to the end.
+func (c *ociClient) blob(desc ociDescriptor) (io.ReadCloser, context.CancelFunc, error) {
+	resp, cancel, err := c.get(fmt.Sprintf("/v2/%s/blobs/%s", c.ref.repository, desc.Digest), "")
+	if err != nil {
+		return nil, cancel, err
+	}
+	verifier, err := newDigestVerifier(resp.Body, desc.Digest)
+	if err != nil {
+		_ = resp.Body.Close()
+		return nil, cancel, err
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{verifier, resp.Body}, cancel, nil
+}
+
+// findInLayer looks for target in the tar layer described by desc. If the
+// file was found, it is returned with found set to true; if it was deleted
+// by a whiteout in this layer, found is true and contents is nil. The whole
+// layer is always read so that its digest is verified.
+func (c *ociClient) findInLayer(desc ociDescriptor, target string) (contents []byte, found bool, err error) {
+	blob, cancel, err := c.blob(desc)
+	if cancel != nil {
+		defer cancel()
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	defer func() {
+		_ = blob.Close()
+	}()
+
+	var layer io.Reader = blob
+	switch {
+	case strings.HasSuffix(desc.MediaType, ".tar"):
+	case strings.HasSuffix(desc.MediaType, ".tar+gzip"), strings.HasSuffix(desc.MediaType, ".tar.gzip"):
+		gz, err := gzip.NewReader(blob)
+		if err != nil {
+			return nil, false, err
+		}
+		defer func() {
+			_ = gz.Close()
+		}()
+		layer = gz
+	default:
+		return nil, false, fmt.Errorf("%w: %q", ErrOCIUnsupportedLayer, desc.MediaType)
+	}
+
+	dir, base := path.Split(target)
+	whiteout := path.Join(dir, ".wh."+base)
+	tr := tar.NewReader(layer)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, false, err
+		}
+		name := strings.TrimPrefix(path.Clean("/"+hdr.Name), "/")
+		switch {
+		case name == target && hdr.Typeflag == tar.TypeReg:
+			if contents, err = io.ReadAll(tr); err != nil {
+				return nil, false, err
+			}
+			found = true
+		case name == whiteout:
+			contents, found = nil, true
+		case path.Base(name) == ".wh..wh..opq" && strings.HasPrefix(target, path.Dir(name)+"/") && !found:
+			// opaque directory: lower layers are hidden
+			found = true
+		}
+	}
+	// drain the blob so the digest is checked
+	if _, err := io.Copy(io.Discard, blob); err != nil {
+		return nil, false, err
+	}
+	return contents, found, nil
+}
+
+// get performs a GET request against the registry, authenticating with a
+// bearer token if the registry asks for one.
+func (c *ociClient) get(endpoint, accept string) (*http.Response, context.CancelFunc, error) {
+	u := url.URL{Scheme: "https", Host: c.ref.registry, Path: endpoint}
+	opts := FetchOptions{Headers: make(http.Header), Retry: c.retry}
+	if accept != "" {
+		opts.Headers.Set("Accept", accept)
+	}
+	for {
+		if c.token != "" {
+			opts.Headers.Set("Authorization", "Bearer "+c.token)
+		}
+		resp, cancel, err := c.client.httpResponseWithHeader(opts, u.String())
+		if err != nil {
+			return nil, cancel, err
+		}
+		switch resp.StatusCode {
+		case http.StatusOK:
+			return resp, cancel, nil
+		case http.StatusUnauthorized:
+			challenge := resp.Header.Get("WWW-Authenticate")
+			_ = resp.Body.Close()
+			if cancel != nil {
+				cancel()
+			}
+			if c.token != "" {
+				// we already authenticated once
+				return nil, nil, ErrFailed
+			}
+			if err := c.authenticate(challenge); err != nil {
+				return nil, nil, err
+			}
+		case http.StatusNotFound:
+			_ = resp.Body.Close()
+			return nil, cancel, ErrNotFound
+		default:
+			_ = resp.Body.Close()
+			return nil, cancel, ErrFailed
+		}
+	}
+}
+
+// authenticate requests a bearer token as described by a
+// "WWW-Authenticate: Bearer realm=...,service=...,scope=..." challenge.
+func (c *ociClient) authenticate(challenge string) error {
+	scheme, params, _ := strings.Cut(challenge, " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		return fmt.Errorf("unsupported registry authentication scheme %q", scheme)
+	}
+	fields := parseAuthParams(params)
+	realm, err := url.Parse(fields["realm"])
+	if err != nil || realm.Host == "" {
+		return fmt.Errorf("invalid registry token realm %q", fields["realm"])
+	}
+	query := realm.Query()
+	if service := fields["service"]; service != "" {
+		query.Set("service", service)
+	}
+	scope := fields["scope"]
+	if scope == "" {
+		scope = fmt.Sprintf("repository:%s:pull", c.ref.repository)
+	}
+	query.Set("scope", scope)
+	realm.RawQuery = query.Encode()
+
+	opts := FetchOptions{Headers: make(http.Header), Retry: c.retry}
+	if c.ref.user != nil {
+		req := http.Request{Header: make(http.Header)}
+		password, _ := c.ref.user.Password()
+		req.SetBasicAuth(c.ref.user.Username(), password)
+		opts.Headers.Set("Authorization", req.Header.Get("Authorization"))
+	}
+	resp, cancel, err := c.client.httpResponseWithHeader(opts, realm.String())
+	if cancel != nil {
+		defer cancel()
+	}
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("requesting registry token: %w", ErrFailed)
+	}
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return fmt.Errorf("parsing registry token: %w", err)
+	}
+	c.token = token.Token
+	if c.token == "" {
+		c.token = token.AccessToken
+	}
+	if c.token == "" {
+		return fmt.Errorf("registry returned an empty token")
+	}
+	return nil
+}
+
+// parseAuthParams parses the comma-separated key="value" pairs of an
+// authentication challenge.
+func parseAuthParams(s string) map[string]string {
+	params := make(map[string]string)
+	for s != "" {
+		var key, value string
+		key, s, _ = strings.Cut(strings.TrimLeft(s, " ,"), "=")
+		if strings.HasPrefix(s, `"`) {
+			value, s, _ = strings.Cut(s[1:], `"`)
+		} else {
+			value, s, _ = strings.Cut(s, ",")
+		}
+		params[strings.ToLower(strings.TrimSpace(key))] = value
+	}
+	return params
+}
@@ -0,0 +1,322 @@
+// Copyright 2026 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coreos/ignition/v2/internal/log"
+	"github.com/coreos/ignition/v2/internal/util"
+)
+
+// fakeRegistry is a minimal OCI distribution server requiring bearer
+// token authentication.
+type fakeRegistry struct {
+	manifests map[string][]byte
+	blobs     map[string][]byte
+}
+
+func ociDigest(b []byte) string {
+	sum := sha256.Sum256(b)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func (r *fakeRegistry) addBlob(b []byte) string {
+	digest := ociDigest(b)
+	r.blobs[digest] = b
+	return digest
+}
+
+func (r *fakeRegistry) addManifest(tag string, layers map[string][]byte, mediaType string) string {
+	var descs []ociDescriptor
+	for _, name := range []string{"lower", "upper", "only"} {
+		if b, ok := layers[name]; ok {
+			descs = append(descs, ociDescriptor{
+				MediaType: mediaType,
+				Digest:    r.addBlob(b),
+				Size:      int64(len(b)),
+			})
+		}
+	}
+	raw, _ := json.Marshal(ociManifest{MediaType: ociManifestMediaType, Layers: descs})
+	digest := ociDigest(raw)
+	r.manifests[tag] = raw
+	r.manifests[digest] = raw
+	return digest
+}
+
+func (r *fakeRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Path == "/token" {
+		if req.URL.Query().Get("scope") != "repository:repo:pull" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_, _ = w.Write([]byte(`{"token": "secret"}`))
+		return
+	}
+	if req.Header.Get("Authorization") != "Bearer secret" {
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="https://%s/token",service="registry",scope="repository:repo:pull"`, req.Host))
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	switch {
+	case strings.HasPrefix(req.URL.Path, "/v2/repo/manifests/"):
+		if m, ok := r.manifests[strings.TrimPrefix(req.URL.Path, "/v2/repo/manifests/")]; ok {
+			w.Header().Set("Content-Type", ociManifestMediaType)
+			_, _ = w.Write(m)
+			return
+		}
+	case strings.HasPrefix(req.URL.Path, "/v2/repo/blobs/"):
+		if b, ok := r.blobs[strings.TrimPrefix(req.URL.Path, "/v2/repo/blobs/")]; ok {
+			_, _ = w.Write(b)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusNotFound)
+}
+
+func tarLayer(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for name, contents := range files {
+		err := tw.WriteHeader(&tar.Header{
+			Name:     name,
+			Mode:     0644,
+			Size:     int64(len(contents)),
+			Typeflag: tar.TypeReg,
+		})
+		assert.NoError(t, err)
+		_, err = tw.Write([]byte(contents))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, tw.Close())
+	return buf.Bytes()
+}
+
+func TestFetchFromOCI(t *testing.T) {
+	registry := &fakeRegistry{
+		manifests: make(map[string][]byte),
+		blobs:     make(map[string][]byte),
+	}
+	artifactDigest := registry.addManifest("artifact", map[string][]byte{
+		"only": []byte("hello world\n"),
+	}, "application/octet-stream")
+	registry.addManifest("image", map[string][]byte{
+		"lower": tarLayer(t, map[string]string{
+			"etc/motd":        "lower motd\n",
+			"etc/issue":       "lower issue\n",
+			"etc/hostname":    "lower hostname\n",
+			"usr/share/empty": "",
+		}),
+		"upper": tarLayer(t, map[string]string{
+			"./etc/motd":       "upper motd\n",
+			"etc/.wh.hostname": "",
+		}),
+	}, "application/vnd.oci.image.layer.v1.tar")
+	// a manifest referencing a corrupted blob
+	corrupt := []byte("corrupted\n")
+	corruptDigest := ociDigest([]byte("original\n"))
+	registry.blobs[corruptDigest] = corrupt
+	raw, _ := json.Marshal(ociManifest{
+		MediaType: ociManifestMediaType,
+		Layers:    []ociDescriptor{{MediaType: "application/octet-stream", Digest: corruptDigest}},
+	})
+	registry.manifests["corrupt"] = raw
+
+	server := httptest.NewTLSServer(registry)
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "https://")
+
+	logger := log.New(true)
+	f := Fetcher{
+		Logger: &logger,
+		client: &HttpClient{
+			client:    server.Client(),
+			logger:    &logger,
+			transport: server.Client().Transport.(*http.Transport),
+			cas:       make(map[string][]byte),
+		},
+	}
+
+	tests := []struct {
+		url  string
+		opts FetchOptions
+		data string
+		err  error
+	}{
+		{
+			url:  "oci://" + host + "/repo:artifact",
+			data: "hello world\n",
+		},
+		{
+			url:  "oci://" + host + "/repo@" + artifactDigest,
+			data: "hello world\n",
+		},
+		{
+			url: "oci://" + host + "/repo:artifact",
+			opts: FetchOptions{
+				Hash:        sha256.New(),
+				ExpectedSum: []byte("\xa9\x48\x90\x4f\x2f\x0f\x47\x9b\x8f\x81\x97\x69\x4b\x30\x18\x4b\x0d\x2e\xd1\xc1\xcd\x2a\x1e\xc0\xfb\x85\xd2\x99\xa1\x92\xa4\x47"),
+			},
+			data: "hello world\n",
+		},
+		{
+			url:  "oci://" + host + "/repo:image?path=/etc/motd",
+			data: "upper motd\n",
+		},
+		{
+			url:  "oci://" + host + "/repo:image?path=etc/issue",
+			data: "lower issue\n",
+		},
+		{
+			url: "oci://" + host + "/repo:image?path=/etc/hostname",
+			err: ErrNotFound,
+		},
+		{
+			url: "oci://" + host + "/repo:image?path=/etc/missing",
+			err: ErrNotFound,
+		},
+		{
+			url: "oci://" + host + "/repo:image",
+			err: ErrOCIAmbiguousArtifact,
+		},
+		{
+			url: "oci://" + host + "/repo:missing",
+			err: ErrNotFound,
+		},
+		{
+			url: "oci://" + host + "/repo@" + ociDigest([]byte("other")),
+			err: ErrNotFound,
+		},
+		{
+			url: "oci://" + host + "/repo:corrupt",
+			err: util.ErrHashMismatch{
+				Calculated: strings.TrimPrefix(ociDigest(corrupt), "sha256:"),
+				Expected:   strings.TrimPrefix(corruptDigest, "sha256:"),
+			},
+		},
+	}
+
+	for i, test := range tests {
+		u, err := url.Parse(test.url)
+		assert.NoError(t, err)
+		data, err := f.FetchToBuffer(*u, test.opts)
+		assert.Equal(t, test.err, err, "#%d: bad error", i)
+		if test.err == nil {
+			assert.Equal(t, test.data, string(data), "#%d: bad data", i)
+		}
+	}
+}
+
+func TestFetchFromOCIRedirect(t *testing.T) {
+	registry := &fakeRegistry{
+		manifests: make(map[string][]byte),
+		blobs:     make(map[string][]byte),
+	}
+	registry.addManifest("artifact", map[string][]byte{
+		"only": []byte("hello world\n"),
+	}, "application/octet-stream")
+
+	// blob storage on another host, which must not see the registry token
+	var storageAuth []string
+	storage := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		storageAuth = append(storageAuth, req.Header.Get("Authorization"))
+		if b, ok := registry.blobs[strings.TrimPrefix(req.URL.Path, "/blobs/")]; ok {
+			_, _ = w.Write(b)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer storage.Close()
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch {
+		case req.URL.Path == "/v2/repo/manifests/moved":
+			// redirects on the registry keep the token
+			http.Redirect(w, req, "/v2/repo/manifests/artifact", http.StatusFound)
+		case strings.HasPrefix(req.URL.Path, "/v2/repo/blobs/") && req.Header.Get("Authorization") != "":
+			http.Redirect(w, req, storage.URL+"/blobs/"+strings.TrimPrefix(req.URL.Path, "/v2/repo/blobs/"), http.StatusTemporaryRedirect)
+		default:
+			registry.ServeHTTP(w, req)
+		}
+	}))
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "https://")
+
+	logger := log.New(true)
+	f := Fetcher{
+		Logger: &logger,
+		client: &HttpClient{
+			client:    server.Client(),
+			logger:    &logger,
+			transport: server.Client().Transport.(*http.Transport),
+			cas:       make(map[string][]byte),
+		},
+	}
+
+	u, err := url.Parse("oci://" + host + "/repo:moved")
+	assert.NoError(t, err)
+	data, err := f.FetchToBuffer(*u, FetchOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "hello world\n", string(data))
+	assert.Equal(t, []string{""}, storageAuth)
+	// the redirect policy doesn't apply to other fetches
+	assert.Nil(t, f.client.client.CheckRedirect)
+}
+
+func TestParseOCIURL(t *testing.T) {
+	tests := []struct {
+		url string
+		ref ociReference
+		err bool
+	}{
+		{
+			url: "oci://registry.example.com/org/repo:v1",
+			ref: ociReference{registry: "registry.example.com", repository: "org/repo", reference: "v1"},
+		},
+		{
+			url: "oci://localhost:5000/repo@sha256:abcd?path=/etc/motd",
+			ref: ociReference{registry: "localhost:5000", repository: "repo", reference: "sha256:abcd", path: "/etc/motd"},
+		},
+		{
+			url: "oci://localhost:5000/repo",
+			err: true,
+		},
+	}
+
+	for i, test := range tests {
+		u, err := url.Parse(test.url)
+		assert.NoError(t, err)
+		ref, err := parseOCIURL(*u)
+		if test.err {
+			assert.Error(t, err, "#%d: expected error", i)
+		} else {
+			assert.NoError(t, err)
+			assert.Equal(t, test.ref, ref, "#%d: bad reference", i)
+		}
+	}
+}
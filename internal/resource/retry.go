@@ -0,0 +1,103 @@
+// Copyright 2026 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"math/rand/v2"
+	"time"
+
+	"github.com/coreos/ignition/v2/config/v3_7_experimental/types"
+)
+
+// retryPolicy controls how many times, and how quickly, failed fetches are
+// retried.
+type retryPolicy struct {
+	// maxAttempts is the maximum number of attempts, or 0 for no limit
+	maxAttempts    int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	// jitter is the maximum random variation of each backoff, as a
+	// percentage of the backoff
+	jitter int
+	// configured is whether any setting was specified in the config,
+	// rather than taken from the defaults
+	configured bool
+}
+
+// newRetryPolicy returns the retry policy for a fetch. Settings specified on
+// the resource take precedence over the global settings from
+// ignition.timeouts, which take precedence over the defaults.
+func newRetryPolicy(global, resource types.Retry) retryPolicy {
+	p := retryPolicy{
+		initialBackoff: initialBackoff,
+		maxBackoff:     maxBackoff,
+	}
+	for _, r := range []types.Retry{global, resource} {
+		if r.MaxAttempts != nil {
+			p.maxAttempts = *r.MaxAttempts
+			p.configured = true
+		}
+		if r.InitialBackoff != nil {
+			p.initialBackoff = time.Duration(*r.InitialBackoff) * time.Millisecond
+			p.configured = true
+		}
+		if r.MaxBackoff != nil {
+			p.maxBackoff = time.Duration(*r.MaxBackoff) * time.Millisecond
+			p.configured = true
+		}
+		if r.Jitter != nil {
+			p.jitter = *r.Jitter
+			p.configured = true
+		}
+	}
+	// an initial backoff above the default maximum raises the maximum
+	if p.initialBackoff > p.maxBackoff {
+		p.maxBackoff = p.initialBackoff
+	}
+	return p
+}
+
+// retryPolicy returns the retry policy for a fetch with the given options.
+func (f *Fetcher) retryPolicy(opts FetchOptions) retryPolicy {
+	var global types.Retry
+	if f.client != nil {
+		global = f.client.retry
+	}
+	return newRetryPolicy(global, opts.Retry)
+}
+
+// exhausted returns whether no attempts remain after the given attempt
+// (counting from 1).
+func (p retryPolicy) exhausted(attempt int) bool {
+	return p.maxAttempts != 0 && attempt >= p.maxAttempts
+}
+
+// backoff returns the time to wait after the given failed attempt (counting
+// from 1). The backoff doubles after each attempt until it reaches the
+// maximum, and is then randomly varied by up to the jitter percentage.
+func (p retryPolicy) backoff(attempt int) time.Duration {
+	d := p.initialBackoff
+	for i := 1; i < attempt && d < p.maxBackoff; i++ {
+		d = d * 2
+	}
+	if d > p.maxBackoff {
+		d = p.maxBackoff
+	}
+	if p.jitter > 0 && d > 0 {
+		spread := int64(d) * int64(p.jitter) / 100
+		d += time.Duration(rand.Int64N(2*spread+1) - spread)
+	}
+	return d
+}
@@ -0,0 +1,175 @@
+// Copyright 2026 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/pin/tftp"
+	"github.com/stretchr/testify/assert"
+
+	cutil "github.com/coreos/ignition/v2/config/util"
+	"github.com/coreos/ignition/v2/config/v3_7_experimental/types"
+	"github.com/coreos/ignition/v2/internal/log"
+)
+
+func TestRetryPolicy(t *testing.T) {
+	// defaults
+	p := newRetryPolicy(types.Retry{}, types.Retry{})
+	assert.False(t, p.configured)
+	assert.False(t, p.exhausted(1000))
+	assert.Equal(t, initialBackoff, p.backoff(1))
+	assert.Equal(t, 2*initialBackoff, p.backoff(2))
+	assert.Equal(t, maxBackoff, p.backoff(100))
+
+	// resource settings override global ones
+	p = newRetryPolicy(types.Retry{
+		MaxAttempts:    cutil.IntToPtr(5),
+		InitialBackoff: cutil.IntToPtr(10),
+	}, types.Retry{
+		MaxAttempts: cutil.IntToPtr(2),
+		MaxBackoff:  cutil.IntToPtr(30),
+	})
+	assert.True(t, p.configured)
+	assert.False(t, p.exhausted(1))
+	assert.True(t, p.exhausted(2))
+	assert.Equal(t, 10*time.Millisecond, p.backoff(1))
+	assert.Equal(t, 20*time.Millisecond, p.backoff(2))
+	assert.Equal(t, 30*time.Millisecond, p.backoff(3))
+
+	// an initial backoff above the default maximum raises the maximum
+	p = newRetryPolicy(types.Retry{InitialBackoff: cutil.IntToPtr(10000)}, types.Retry{})
+	assert.Equal(t, 10*time.Second, p.backoff(3))
+
+	// jitter stays within bounds
+	p = newRetryPolicy(types.Retry{
+		InitialBackoff: cutil.IntToPtr(1000),
+		Jitter:         cutil.IntToPtr(10),
+	}, types.Retry{})
+	for i := 0; i < 100; i++ {
+		d := p.backoff(1)
+		assert.GreaterOrEqual(t, d, 900*time.Millisecond)
+		assert.LessOrEqual(t, d, 1100*time.Millisecond)
+	}
+}
+
+func TestFetchFromHTTPMaxAttempts(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	u, err := url.Parse(server.URL)
+	assert.NoError(t, err)
+
+	logger := log.New(true)
+	f := Fetcher{Logger: &logger}
+	err = f.UpdateHttpTimeoutsAndCAs(types.Timeouts{
+		Retry: types.Retry{
+			MaxAttempts:    cutil.IntToPtr(3),
+			InitialBackoff: cutil.IntToPtr(1),
+		},
+	}, types.TLS{}, types.Proxy{})
+	assert.NoError(t, err)
+
+	_, err = f.FetchToBuffer(*u, FetchOptions{})
+	assert.Equal(t, ErrFailed, err)
+	assert.Equal(t, 3, requests)
+
+	// a per-resource override takes precedence
+	requests = 0
+	_, err = f.FetchToBuffer(*u, FetchOptions{
+		Retry: types.Retry{MaxAttempts: cutil.IntToPtr(1)},
+	})
+	assert.Equal(t, ErrFailed, err)
+	assert.Equal(t, 1, requests)
+
+	// connection errors give up too
+	server.Close()
+	_, err = f.FetchToBuffer(*u, FetchOptions{})
+	assert.Error(t, err)
+}
+
+// failingReader returns an error after the first n bytes of r.
+type failingReader struct {
+	r io.Reader
+	n int
+}
+
+func (fr *failingReader) Read(p []byte) (int, error) {
+	if fr.n <= 0 {
+		return 0, errors.New("transfer interrupted")
+	}
+	if len(p) > fr.n {
+		p = p[:fr.n]
+	}
+	n, err := fr.r.Read(p)
+	fr.n -= n
+	return n, err
+}
+
+func TestFetchFromTFTPMaxAttempts(t *testing.T) {
+	contents := bytes.Repeat([]byte("0123456789abcdef"), 256)
+	requests := 0
+	server := tftp.NewServer(func(filename string, rf io.ReaderFrom) error {
+		requests++
+		var r io.Reader = bytes.NewReader(contents)
+		switch requests {
+		case 1:
+			return errors.New("not ready")
+		case 2:
+			// fail after a few packets
+			r = &failingReader{r: r, n: 1500}
+		}
+		_, err := rf.ReadFrom(r)
+		return err
+	}, nil)
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	assert.NoError(t, err)
+	go server.Serve(conn)
+	defer server.Shutdown()
+	u, err := url.Parse(fmt.Sprintf("tftp://%s/contents", conn.LocalAddr()))
+	assert.NoError(t, err)
+
+	logger := log.New(true)
+	f := Fetcher{Logger: &logger}
+
+	// without a limit on the attempts, a single transfer is attempted
+	_, err = f.FetchToBuffer(*u, FetchOptions{})
+	assert.Error(t, err)
+	assert.Equal(t, 1, requests)
+
+	// failed and interrupted transfers are retried
+	requests = 0
+	data, err := f.FetchToBuffer(*u, FetchOptions{
+		Retry: types.Retry{
+			MaxAttempts:    cutil.IntToPtr(3),
+			InitialBackoff: cutil.IntToPtr(1),
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, contents, data)
+	assert.Equal(t, 3, requests)
+}
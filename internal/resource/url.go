@@ -36,9 +36,11 @@ import (
 	"cloud.google.com/go/compute/metadata"
 	"cloud.google.com/go/storage"
 	configErrors "github.com/coreos/ignition/v2/config/shared/errors"
+	cutil "github.com/coreos/ignition/v2/config/util"
 	"github.com/coreos/ignition/v2/internal/log"
 	"github.com/coreos/ignition/v2/internal/util"
 	"github.com/coreos/vcontext/report"
+	"github.com/googleapis/gax-go/v2"
 	"golang.org/x/oauth2/google"
 	"google.golang.org/api/option"
 
@@ -49,6 +51,7 @@ import (
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
 	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/pin/tftp"
@@ -102,6 +105,11 @@ type Fetcher struct {
 	// This is used as a hint to fetch the S3 bucket from the right partition and region.
 	S3RegionHint string
 
+	// S3Config holds the endpoint, region, addressing style, and
+	// credentials to use for S3-compatible object storage services, as
+	// set in the ignition.s3 section of the config.
+	S3Config types.S3
+
 	// GCSSession is a client for interacting with Google Cloud Storage.
 	// It is used when fetching resources from GCS.
 	GCSSession *storage.Client
@@ -147,6 +155,10 @@ type FetchOptions struct {
 	// List of HTTP codes to retry that usually would be considered as complete.
 	// Status codes >= 500 are always retried.
 	RetryCodes []int
+
+	// Retry overrides the retry settings from ignition.timeouts for this
+	// fetch.
+	Retry types.Retry
 }
 
 // FetchToBuffer will fetch the given url into a temporary file, and then read
@@ -184,6 +196,8 @@ func (f *Fetcher) FetchToBuffer(u url.URL, opts FetchOptions) ([]byte, error) {
 		return buf.Bytes(), err
 	case "gs":
 		err = f.fetchFromGCS(u, dest, opts)
+	case "oci":
+		err = f.fetchFromOCI(u, dest, opts)
 	case "":
 		return nil, nil
 	default:
@@ -252,6 +266,8 @@ func (f *Fetcher) Fetch(u url.URL, dest *os.File, opts FetchOptions) error {
 		return f.fetchFromS3(u, dest, opts)
 	case "gs":
 		return f.fetchFromGCS(u, dest, opts)
+	case "oci":
+		return f.fetchFromOCI(u, dest, opts)
 	case "":
 		return nil
 	default:
@@ -269,58 +285,109 @@ func (f *Fetcher) fetchFromTFTP(u url.URL, dest io.Writer, opts FetchOptions) er
 	if err != nil {
 		return err
 	}
-	wt, err := c.Receive(u.Path, "octet")
-	if err != nil {
+	body := &tftpBody{
+		f:      f,
+		client: c,
+		url:    u,
+		policy: f.retryPolicy(opts),
+	}
+	if err := body.start(); err != nil {
 		return err
 	}
-	// The TFTP library takes an io.Writer to send data in to, but to decompress
-	// the stream the gzip library wraps an io.Reader, so let's create a pipe to
-	// connect these two things
-	pReader, pWriter := io.Pipe()
-	doneChan := make(chan error, 2)
-
-	checkForDoneChanErr := func(err error) error {
-		// If an error is encountered while decompressing or copying data out of
-		// the pipe, there's probably an error from writing into the pipe that
-		// will better describe what went wrong. This function does a
-		// non-blocking read of doneChan, overriding the returned error val if
-		// there's anything in doneChan.
-		select {
-		case writeErr := <-doneChan:
-			if writeErr != nil {
-				return writeErr
-			}
-			return err
-		default:
+	defer func() {
+		_ = body.Close()
+	}()
+	return f.decompressCopyHashAndVerify(dest, body, opts)
+}
+
+// tftpBody reads a resource over TFTP. The TFTP library only retries
+// individual packets, so failed transfers are retried as a whole following
+// the retry policy, skipping the bytes already read. Without a limit on the
+// number of attempts, a single transfer is attempted, since TFTP has no
+// total timeout to bound the retries.
+type tftpBody struct {
+	f      *Fetcher
+	client *tftp.Client
+	url    url.URL
+	policy retryPolicy
+
+	r       *io.PipeReader
+	attempt int
+	offset  int64
+}
+
+// start starts a transfer, retrying it following the retry policy.
+func (b *tftpBody) start() error {
+	for {
+		b.attempt++
+		b.f.Logger.Info("GET %s: attempt #%d", b.url.String(), b.attempt)
+		err := b.open()
+		if err == nil {
+			return nil
+		}
+		b.f.Logger.Info("GET error: %v", err)
+		if !b.wait() {
 			return err
 		}
 	}
+}
 
-	// A goroutine is used to handle writing the fetched data into the pipe
-	// while also copying it out of the pipe concurrently
-	go func() {
-		_, err := wt.WriteTo(pWriter)
-		doneChan <- err
-		err = pWriter.Close()
-		doneChan <- err
-	}()
-	err = f.decompressCopyHashAndVerify(dest, pReader, opts)
-	if err != nil {
-		return checkForDoneChanErr(err)
+// wait waits before the next attempt. It returns false if no attempts
+// remain.
+func (b *tftpBody) wait() bool {
+	if b.policy.maxAttempts == 0 || b.policy.exhausted(b.attempt) {
+		if b.attempt > 1 {
+			b.f.Logger.Info("GET %s: giving up after %d attempts", b.url.String(), b.attempt)
+		}
+		return false
 	}
-	// receive the error from wt.WriteTo()
-	err = <-doneChan
+	time.Sleep(b.policy.backoff(b.attempt))
+	return true
+}
+
+// open starts a transfer and skips the bytes already read.
+func (b *tftpBody) open() error {
+	wt, err := b.client.Receive(b.url.Path, "octet")
 	if err != nil {
 		return err
 	}
-	// receive the error from pWriter.Close()
-	err = <-doneChan
-	if err != nil {
+	// The TFTP library takes an io.Writer to send data in to, but the
+	// reader wraps an io.Reader, so let's create a pipe to connect these
+	// two things
+	pReader, pWriter := io.Pipe()
+	go func() {
+		_, err := wt.WriteTo(pWriter)
+		_ = pWriter.CloseWithError(err)
+	}()
+	if _, err := io.CopyN(io.Discard, pReader, b.offset); err != nil {
+		_ = pReader.Close()
 		return err
 	}
+	b.r = pReader
 	return nil
 }
 
+func (b *tftpBody) Read(p []byte) (int, error) {
+	n, err := b.r.Read(p)
+	b.offset += int64(n)
+	if err == nil || err == io.EOF {
+		return n, err
+	}
+	b.f.Logger.Info("GET %s: transfer failed: %v", b.url.String(), err)
+	_ = b.r.Close()
+	if !b.wait() {
+		return n, err
+	}
+	if err := b.start(); err != nil {
+		return n, err
+	}
+	return n, nil
+}
+
+func (b *tftpBody) Close() error {
+	return b.r.Close()
+}
+
 // FetchFromHTTP fetches a resource from u via HTTP(S) into dest, returning an
 // error if one is encountered.
 func (f *Fetcher) fetchFromHTTP(u url.URL, dest io.Writer, opts FetchOptions) error {
@@ -452,7 +519,22 @@ func (f *Fetcher) fetchFromGCS(u url.URL, dest io.Writer, opts FetchOptions) err
 	path := strings.TrimLeft(u.Path, "/")
 	ctx, cancel := context.WithTimeout(ctx, time.Second*50)
 	defer cancel()
-	rc, err := f.GCSSession.Bucket(u.Host).Object(path).NewReader(ctx)
+	obj := f.GCSSession.Bucket(u.Host).Object(path)
+	if policy := f.retryPolicy(opts); policy.configured {
+		// the GCS library always applies full jitter
+		retryOpts := []storage.RetryOption{
+			storage.WithBackoff(gax.Backoff{
+				Initial:    policy.initialBackoff,
+				Max:        policy.maxBackoff,
+				Multiplier: 2,
+			}),
+		}
+		if policy.maxAttempts > 0 {
+			retryOpts = append(retryOpts, storage.WithMaxAttempts(policy.maxAttempts))
+		}
+		obj = obj.Retryer(retryOpts...)
+	}
+	rc, err := obj.NewReader(ctx)
 	if err != nil {
 		return fmt.Errorf("error while reading content from (%q): %v", u.String(), err)
 	}
@@ -512,6 +594,29 @@ func (f *Fetcher) fetchFromS3(u url.URL, dest s3target, opts FetchOptions) error
 		f.AWSConfig = &aws.Config{Credentials: aws.AnonymousCredentials{}}
 	}
 	cfg := *f.AWSConfig
+	if cutil.NotEmpty(f.S3Config.AccessKeyID) && cutil.NotEmpty(f.S3Config.SecretAccessKey) {
+		creds := aws.Credentials{
+			AccessKeyID:     *f.S3Config.AccessKeyID,
+			SecretAccessKey: *f.S3Config.SecretAccessKey,
+			Source:          "IgnitionConfig",
+		}
+		cfg.Credentials = aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+			return creds, nil
+		})
+	}
+
+	endpoint := ""
+	if cutil.NotEmpty(f.S3Config.Endpoint) {
+		endpoint = *f.S3Config.Endpoint
+	}
+	if region == "" && cutil.NotEmpty(f.S3Config.Region) {
+		region = *f.S3Config.Region
+	}
+	if region == "" && endpoint != "" {
+		// S3-compatible services generally don't implement bucket
+		// region lookups and accept any region in the signature.
+		region = "us-east-1"
+	}
 
 	// Determine the partition and region this bucket is in
 	if region == "" {
@@ -554,20 +659,39 @@ func (f *Fetcher) fetchFromS3(u url.URL, dest s3target, opts FetchOptions) error
 		VersionId: versionId,
 	}
 
-	client := s3.NewFromConfig(cfg, func(o *s3.Options) {
+	policy := f.retryPolicy(opts)
+	clientOptions := func(o *s3.Options) {
 		o.Region = region
 		o.HTTPClient = f.client.client
-		o.EndpointOptions.UseDualStackEndpoint = aws.DualStackEndpointStateEnabled
-	})
+		if policy.configured {
+			o.Retryer = retry.NewStandard(func(so *retry.StandardOptions) {
+				if policy.maxAttempts > 0 {
+					so.MaxAttempts = policy.maxAttempts
+				}
+				so.MaxBackoff = policy.maxBackoff
+				so.Backoff = retry.BackoffDelayerFunc(func(attempt int, _ error) (time.Duration, error) {
+					return policy.backoff(attempt), nil
+				})
+			})
+		}
+		if f.S3Config.UsePathStyle != nil {
+			o.UsePathStyle = *f.S3Config.UsePathStyle
+		}
+		if endpoint != "" {
+			// dual-stack endpoints can't be combined with a custom
+			// endpoint
+			o.BaseEndpoint = aws.String(endpoint)
+		} else {
+			o.EndpointOptions.UseDualStackEndpoint = aws.DualStackEndpointStateEnabled
+		}
+	}
+	client := s3.NewFromConfig(cfg, clientOptions)
 
 	if err := f.fetchFromS3WithClient(ctx, dest, input, client); err != nil {
 		// Fallback to anonymous credentials if we failed to retrieve an EC2 IMDS role.
 		// The SDK does not provide a typed error for this case.
 		if strings.Contains(err.Error(), "EC2 IMDS role") {
-			anonClient := s3.NewFromConfig(cfg, func(o *s3.Options) {
-				o.Region = region
-				o.HTTPClient = f.client.client
-				o.EndpointOptions.UseDualStackEndpoint = aws.DualStackEndpointStateEnabled
+			anonClient := s3.NewFromConfig(cfg, clientOptions, func(o *s3.Options) {
 				o.Credentials = aws.AnonymousCredentials{}
 			})
 			if err2 := f.fetchFromS3WithClient(ctx, dest, input, anonClient); err2 != nil {
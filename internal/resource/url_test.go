@@ -18,13 +18,19 @@ import (
 	"compress/gzip"
 	"crypto/sha512"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
 	"github.com/coreos/ignition/v2/config/shared/errors"
+	cutil "github.com/coreos/ignition/v2/config/util"
+	"github.com/coreos/ignition/v2/config/v3_7_experimental/types"
 	"github.com/coreos/ignition/v2/internal/log"
 	"github.com/coreos/ignition/v2/internal/util"
 )
@@ -340,6 +346,84 @@ func TestParseARN(t *testing.T) {
 	}
 }
 
+func TestFetchFromS3Endpoint(t *testing.T) {
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		if r.URL.Path != "/bucket/dir/object" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		http.ServeContent(w, r, "object", time.Time{}, strings.NewReader("hello world\n"))
+	}))
+	defer server.Close()
+
+	logger := log.New(true)
+	f := Fetcher{
+		Logger: &logger,
+		client: &HttpClient{
+			client:    server.Client(),
+			logger:    &logger,
+			transport: server.Client().Transport.(*http.Transport),
+			cas:       make(map[string][]byte),
+		},
+	}
+
+	tests := []struct {
+		url    string
+		config types.S3
+		auth   string
+		err    bool
+	}{
+		{
+			url: "s3://bucket/dir/object",
+			config: types.S3{
+				Endpoint:     cutil.StrToPtr(server.URL),
+				UsePathStyle: cutil.BoolToPtr(true),
+			},
+		},
+		{
+			url: "s3://bucket/dir/object",
+			config: types.S3{
+				Endpoint:        cutil.StrToPtr(server.URL),
+				Region:          cutil.StrToPtr("garage"),
+				UsePathStyle:    cutil.BoolToPtr(true),
+				AccessKeyID:     cutil.StrToPtr("AKIDEXAMPLE"),
+				SecretAccessKey: cutil.StrToPtr("secret"),
+			},
+			auth: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/",
+		},
+		{
+			url: "s3://bucket/dir/missing",
+			config: types.S3{
+				Endpoint:     cutil.StrToPtr(server.URL),
+				UsePathStyle: cutil.BoolToPtr(true),
+			},
+			err: true,
+		},
+	}
+
+	for i, test := range tests {
+		authorization = ""
+		f.S3Config = test.config
+		u, err := url.Parse(test.url)
+		assert.NoError(t, err)
+		data, err := f.FetchToBuffer(*u, FetchOptions{})
+		if test.err {
+			assert.Error(t, err, "#%d: expected error", i)
+			continue
+		}
+		assert.NoError(t, err, "#%d: unexpected error", i)
+		assert.Equal(t, "hello world\n", string(data), "#%d: bad data", i)
+		if test.auth == "" {
+			assert.Empty(t, authorization, "#%d: unexpected authorization", i)
+		} else {
+			assert.True(t, strings.HasPrefix(authorization, test.auth), "#%d: bad authorization %q", i, authorization)
+			assert.Contains(t, authorization, "/garage/s3/", "#%d: bad region", i)
+		}
+	}
+}
+
 func TestParseAzureStorageUrl(t *testing.T) {
 	tests := []struct {
 		url            url.URL
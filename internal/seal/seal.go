@@ -0,0 +1,182 @@
+// Copyright 2026 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package seal encrypts the files Ignition keeps in /run between stages,
+// with an ephemeral key held in the kernel keyring. Once the key is revoked
+// at the end of provisioning, the files can no longer be read.
+package seal
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	keyType   = "user"
+	keyPrefix = "ignition:"
+	keySize   = 32
+
+	// keyPerm only lets processes possessing the key use it, rather
+	// than every process of the user
+	keyPerm = keyPossessorAll
+
+	keyPossessorAll = 0x3f000000
+)
+
+// magic prefixes sealed files, distinguishing them from plaintext ones.
+var magic = []byte("IGNSEALED1\n")
+
+var (
+	ErrNoKey     = errors.New("file is encrypted but the kernel keyring has no key for it")
+	ErrTruncated = errors.New("encrypted file is truncated")
+	// ErrUnavailable is returned when the kernel keyring can't be used,
+	// such as in containers whose seccomp policy blocks keyctl or on
+	// kernels built without key support.
+	ErrUnavailable = errors.New("kernel keyring is unavailable")
+)
+
+// Key encrypts and decrypts files. A nil Key leaves files in plaintext.
+type Key []byte
+
+// GetKey returns the named key from the user keyring, creating it if
+// needed. Separate runs of Ignition, such as ones with different state
+// files, should use different names.
+func GetKey(name string) (Key, error) {
+	if err := possessUserKeys(); err != nil {
+		return nil, err
+	}
+	id, err := unix.KeyctlSearch(unix.KEY_SPEC_USER_KEYRING, keyType, keyPrefix+name, 0)
+	if err == unix.ENOKEY {
+		return createKey(name)
+	} else if err != nil {
+		return nil, keyringError("searching kernel keyring", err)
+	}
+
+	key := make(Key, keySize)
+	n, err := unix.KeyctlBuffer(unix.KEYCTL_READ, id, key, 0)
+	if err != nil {
+		return nil, keyringError("reading key", err)
+	}
+	if n != keySize {
+		return nil, fmt.Errorf("key %q has unexpected size %d", keyPrefix+name, n)
+	}
+	return key, nil
+}
+
+func createKey(name string) (Key, error) {
+	key := make(Key, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("generating key: %w", err)
+	}
+	id, err := unix.AddKey(keyType, keyPrefix+name, key, unix.KEY_SPEC_USER_KEYRING)
+	if err != nil {
+		return nil, keyringError("adding key to kernel keyring", err)
+	}
+	if _, err := unix.KeyctlInt(unix.KEYCTL_SETPERM, id, keyPerm, 0, 0); err != nil {
+		return nil, fmt.Errorf("setting key permissions: %w", err)
+	}
+	return key, nil
+}
+
+// possessUserKeys links the user keyring into the session keyring, so the
+// process possesses the keys in it. Each stage runs with a private session
+// keyring, which doesn't otherwise lead to the user keyring.
+func possessUserKeys() error {
+	if _, err := unix.KeyctlInt(unix.KEYCTL_LINK, unix.KEY_SPEC_USER_KEYRING, unix.KEY_SPEC_SESSION_KEYRING, 0, 0); err != nil {
+		return keyringError("linking user keyring", err)
+	}
+	return nil
+}
+
+// keyringError wraps an error from a keyring syscall, marking the errors
+// which mean the keyring can't be used at all.
+func keyringError(op string, err error) error {
+	if err == unix.ENOSYS || err == unix.EPERM || err == unix.EACCES {
+		return fmt.Errorf("%s: %w: %w", op, ErrUnavailable, err)
+	}
+	return fmt.Errorf("%s: %w", op, err)
+}
+
+// RevokeKey removes the named key from the keyring, if it exists.
+func RevokeKey(name string) error {
+	if err := possessUserKeys(); err != nil {
+		return err
+	}
+	id, err := unix.KeyctlSearch(unix.KEY_SPEC_USER_KEYRING, keyType, keyPrefix+name, 0)
+	if err == unix.ENOKEY {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("searching kernel keyring: %w", err)
+	}
+	if _, err := unix.KeyctlInt(unix.KEYCTL_INVALIDATE, id, 0, 0, 0); err != nil {
+		return fmt.Errorf("invalidating key: %w", err)
+	}
+	return nil
+}
+
+// Seal encrypts the data, or returns it unchanged if the key is nil.
+func (k Key) Seal(data []byte) ([]byte, error) {
+	if k == nil {
+		return data, nil
+	}
+	aead, err := k.aead()
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("generating nonce: %w", err)
+	}
+	ret := append(bytes.Clone(magic), nonce...)
+	return aead.Seal(ret, nonce, data, magic), nil
+}
+
+// Open decrypts data sealed with the key. Plaintext data, such as files
+// written with a nil key, is returned unchanged.
+func (k Key) Open(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, magic) {
+		return data, nil
+	}
+	if k == nil {
+		return nil, ErrNoKey
+	}
+	aead, err := k.aead()
+	if err != nil {
+		return nil, err
+	}
+	data = data[len(magic):]
+	if len(data) < aead.NonceSize() {
+		return nil, ErrTruncated
+	}
+	nonce, ciphertext := data[:aead.NonceSize()], data[aead.NonceSize():]
+	ret, err := aead.Open(nil, nonce, ciphertext, magic)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting file: %w", err)
+	}
+	return ret, nil
+}
+
+func (k Key) aead() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(k)
+	if err != nil {
+		return nil, fmt.Errorf("creating cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
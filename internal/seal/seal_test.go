@@ -0,0 +1,107 @@
+// Copyright 2026 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package seal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+func TestSealOpen(t *testing.T) {
+	key := Key(bytes.Repeat([]byte{1}, keySize))
+	other := Key(bytes.Repeat([]byte{2}, keySize))
+	plaintext := []byte(`{"ignition":{"version":"3.7.0-experimental"}}`)
+
+	sealed, err := key.Seal(plaintext)
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(sealed, magic))
+	assert.NotContains(t, string(sealed), "ignition\"")
+
+	opened, err := key.Open(sealed)
+	assert.NoError(t, err)
+	assert.Equal(t, plaintext, opened)
+
+	// sealing twice uses different nonces
+	again, err := key.Seal(plaintext)
+	require.NoError(t, err)
+	assert.NotEqual(t, sealed, again)
+
+	_, err = other.Open(sealed)
+	assert.Error(t, err)
+	_, err = Key(nil).Open(sealed)
+	assert.Equal(t, ErrNoKey, err)
+	_, err = key.Open(sealed[:len(magic)+4])
+	assert.Equal(t, ErrTruncated, err)
+
+	tampered := bytes.Clone(sealed)
+	tampered[len(tampered)-1] ^= 1
+	_, err = key.Open(tampered)
+	assert.Error(t, err)
+
+	// a nil key and plaintext files pass through
+	out, err := Key(nil).Seal(plaintext)
+	assert.NoError(t, err)
+	assert.Equal(t, plaintext, out)
+	out, err = key.Open(plaintext)
+	assert.NoError(t, err)
+	assert.Equal(t, plaintext, out)
+}
+
+func TestKeyring(t *testing.T) {
+	name := fmt.Sprintf("test-%d", os.Getpid())
+	key, err := GetKey(name)
+	if errors.Is(err, ErrUnavailable) {
+		t.Skipf("kernel keyring unavailable: %v", err)
+	}
+	require.NoError(t, err)
+	defer func() { _ = RevokeKey(name) }()
+	assert.Len(t, key, keySize)
+	// only possessors may use the key
+	id, err := unix.KeyctlSearch(unix.KEY_SPEC_USER_KEYRING, keyType, keyPrefix+name, 0)
+	require.NoError(t, err)
+	desc, err := unix.KeyctlString(unix.KEYCTL_DESCRIBE, id)
+	require.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("%s;0;0;%08x;%s%s", keyType, keyPerm, keyPrefix, name), desc)
+
+	same, err := GetKey(name)
+	assert.NoError(t, err)
+	assert.Equal(t, key, same)
+
+	assert.NoError(t, RevokeKey(name))
+	// revoking a missing key is fine
+	assert.NoError(t, RevokeKey(name))
+
+	fresh, err := GetKey(name)
+	assert.NoError(t, err)
+	assert.NotEqual(t, key, fresh)
+}
+
+func TestKeyringError(t *testing.T) {
+	for _, errno := range []unix.Errno{unix.ENOSYS, unix.EPERM, unix.EACCES} {
+		err := keyringError("searching kernel keyring", errno)
+		assert.ErrorIs(t, err, ErrUnavailable, "%v", errno)
+		assert.ErrorIs(t, err, errno)
+	}
+	err := keyringError("searching kernel keyring", unix.EDQUOT)
+	assert.NotErrorIs(t, err, ErrUnavailable)
+	assert.ErrorIs(t, err, unix.EDQUOT)
+}
@@ -21,6 +21,7 @@ import (
 	"path/filepath"
 
 	"github.com/coreos/ignition/v2/config/v3_7_experimental/types"
+	"github.com/coreos/ignition/v2/internal/seal"
 )
 
 type State struct {
@@ -44,15 +45,33 @@ type State struct {
 	// Volume Key files generated during LUKS setup in disks stage, which
 	// need to be written out during files stage.
 	LuksPersistSecureKeyRepoFiles map[string]string `json:"luksPersistVolumeKeyFiles"`
+	// Remote resources downloaded ahead of time by the fetch stage,
+	// mapping a cache key to the path of the cached contents.  If
+	// non-nil, subsequent stages run with an offline fetcher and read
+	// resources from here instead of the network.
+	FetchCache map[string]string `json:"fetchCache"`
+	// SSH public keys provided by the platform, fetched by the fetch
+	// stages if any user is to be authorized with them, and added to
+	// those users during files stage.  Nil if not fetched.
+	PlatformSSHKeys []string `json:"platformSSHKeys"`
+	// Platform detected by the first stage if none was given on the
+	// command line.  Later stages use it instead of detecting the
+	// platform again.
+	Platform string `json:"platform"`
 }
 
 type FetchedConfig struct {
 	Kind       string `json:"kind"`
 	Source     string `json:"source"`
 	Referenced bool   `json:"referenced"`
+	// Index in FetchedConfigs of the config which referenced this one,
+	// if recorded.
+	Parent *int `json:"parent,omitempty"`
 }
 
-func Load(path string) (State, error) {
+// Load reads the state file, decrypting it with the key if it was saved
+// encrypted.
+func Load(path string, key seal.Key) (State, error) {
 	data, err := os.ReadFile(path)
 	if os.IsNotExist(err) {
 		// valid; return empty struct
@@ -60,6 +79,9 @@ func Load(path string) (State, error) {
 	} else if err != nil {
 		return State{}, fmt.Errorf("reading state file: %w", err)
 	}
+	if data, err = key.Open(data); err != nil {
+		return State{}, fmt.Errorf("decrypting state file: %w", err)
+	}
 	var state State
 	if err = json.Unmarshal(data, &state); err != nil {
 		return State{}, fmt.Errorf("parsing state file: %w", err)
@@ -67,12 +89,16 @@ func Load(path string) (State, error) {
 	return state, nil
 }
 
-func (s *State) Save(path string) error {
+// Save writes the state file, encrypted with the key unless it's nil.
+func (s *State) Save(path string, key seal.Key) error {
 	data, err := json.Marshal(s)
 	if err != nil {
 		return fmt.Errorf("serializing state file: %w", err)
 	}
 	data = append(data, '\n')
+	if data, err = key.Seal(data); err != nil {
+		return fmt.Errorf("encrypting state file: %w", err)
+	}
 	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
 		return fmt.Errorf("creating directory for state file: %w", err)
 	}
@@ -293,10 +293,21 @@ func outer(t *testing.T, test types.Test, negativeTests bool) error {
 	// Ignition
 	appendEnv := test.Env
 	appendEnv = append(appendEnv, "IGNITION_SYSTEM_CONFIG_DIR="+systemConfigDir)
+	appendEnv = append(appendEnv, "IGNITION_FETCH_CACHE_DIR="+filepath.Join(tmpDirectory, "fetch-cache"))
 
 	if !negativeTests {
-		if err := runIgnition(t, ctx, "fetch", "", tmpDirectory, appendEnv, test.SkipCriticalCheck); err != nil {
-			return err
+		if test.FetchOffline {
+			if err := runIgnition(t, ctx, "fetch-offline", "", tmpDirectory, appendEnv, test.SkipCriticalCheck); err != nil {
+				return err
+			}
+		}
+
+		// Like ignition-fetch.service, skip the fetch stage if the
+		// fetch-offline stage cached the config
+		if _, err := os.Stat(filepath.Join(tmpDirectory, "ignition.json")); !test.FetchOffline || os.IsNotExist(err) {
+			if err := runIgnition(t, ctx, "fetch", "", tmpDirectory, appendEnv, test.SkipCriticalCheck); err != nil {
+				return err
+			}
 		}
 
 		if err := runIgnition(t, ctx, "disks", "", tmpDirectory, appendEnv, test.SkipCriticalCheck); err != nil {
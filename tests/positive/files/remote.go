@@ -29,6 +29,7 @@ func init() {
 	register.Register(register.PositiveTest, CreateFileFromRemoteContentsHTTPUsingHeadersWithRedirect())
 	register.Register(register.PositiveTest, CreateFileFromRemoteContentsHTTPUsingOverwrittenHeaders())
 	register.Register(register.PositiveTest, CreateFileFromRemoteContentsTFTP())
+	register.Register(register.PositiveTest, CreateFileFromRemoteContentsHTTPAfterFetchOffline())
 }
 
 func CreateFileFromRemoteContentsHTTP() types.Test {
@@ -252,3 +253,42 @@ func CreateFileFromRemoteContentsTFTP() types.Test {
 		ConfigMinVersion: configMinVersion,
 	}
 }
+
+// CreateFileFromRemoteContentsHTTPAfterFetchOffline runs the fetch-offline
+// stage, which fetches the config, so the fetch stage is skipped and the
+// following stage prefetches the remote contents.
+func CreateFileFromRemoteContentsHTTPAfterFetchOffline() types.Test {
+	name := "files.create.http.fetchoffline"
+	in := types.GetBaseDisk()
+	out := types.GetBaseDisk()
+	config := `{
+	  "ignition": { "version": "$version" },
+	  "storage": {
+	    "files": [{
+	      "path": "/foo/bar",
+	      "contents": {
+	        "source": "http://127.0.0.1:8080/contents"
+	      }
+	    }]
+	  }
+	}`
+	out[0].Partitions.AddFiles("ROOT", []types.File{
+		{
+			Node: types.Node{
+				Name:      "bar",
+				Directory: "foo",
+			},
+			Contents: "asdf\nfdsa",
+		},
+	})
+	configMinVersion := "3.0.0"
+
+	return types.Test{
+		Name:             name,
+		In:               in,
+		Out:              out,
+		Config:           config,
+		ConfigMinVersion: configMinVersion,
+		FetchOffline:     true,
+	}
+}
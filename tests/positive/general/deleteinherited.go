@@ -0,0 +1,297 @@
+// Copyright 2026 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package general
+
+import (
+	"encoding/base64"
+	"strings"
+
+	"github.com/coreos/ignition/v2/tests/register"
+	"github.com/coreos/ignition/v2/tests/types"
+)
+
+func init() {
+	register.Register(register.PositiveTest, VerifyDeleteInheritedEntries())
+	register.Register(register.PositiveTest, VerifyDeleteInheritedFromLaterFragment())
+	register.Register(register.PositiveTest, VerifyDeleteInheritedFromMergedConfig())
+}
+
+var (
+	deletingBaseConfig = []byte(`{
+				"ignition": { "version": "3.0.0" },
+				"storage": {
+					"files": [{
+						"path": "/foo/base1",
+						"contents": { "source": "data:,base%20config%0A" }
+					},
+					{
+						"path": "/foo/base2",
+						"contents": { "source": "data:,base%20config%0A" }
+					}]
+				}
+			}`)
+	deletingOverrideConfig = []byte(`{
+				"ignition": { "version": "3.7.0-experimental" },
+				"storage": {
+					"files": [{
+						"path": "/foo/user2",
+						"deleteInherited": true
+					}]
+				}
+			}`)
+	firstOverrideFragment = []byte(`{
+				"ignition": { "version": "3.7.0-experimental" },
+				"storage": {
+					"files": [{
+						"path": "/foo/override1",
+						"contents": { "source": "data:,override%20config%0A" }
+					}]
+				}
+			}`)
+	unitBaseConfig = []byte(`{
+				"ignition": { "version": "3.0.0" },
+				"systemd": {
+					"units": [{
+						"name": "deleted.service",
+						"contents": "[Unit]\nDescription=base config"
+					}]
+				}
+			}`)
+	deletingMergedConfig = []byte(`{
+				"ignition": { "version": "3.7.0-experimental" },
+				"systemd": {
+					"units": [{
+						"name": "deleted.service",
+						"deleteInherited": true
+					}]
+				}
+			}`)
+	secondOverrideFragment = []byte(`{
+				"ignition": { "version": "3.7.0-experimental" },
+				"storage": {
+					"files": [{
+						"path": "/foo/user1",
+						"deleteInherited": true
+					}]
+				}
+			}`)
+)
+
+// VerifyDeleteInheritedEntries checks if the user config can delete
+// entries of the base config, and the override config can delete
+// entries of the user config.
+func VerifyDeleteInheritedEntries() types.Test {
+	name := "merge.delete.inherited"
+	in := types.GetBaseDisk()
+	out := types.GetBaseDisk()
+	config := `{
+		"ignition": {"version": "$version"},
+		"storage": {
+			"files": [{
+				"path": "/foo/base1",
+				"deleteInherited": true
+			},
+			{
+				"path": "/foo/user1",
+				"contents": { "source": "data:,user%20config%0A" }
+			},
+			{
+				"path": "/foo/user2",
+				"contents": { "source": "data:,user%20config%0A" }
+			}]
+		}
+	}`
+	configMinVersion := "3.7.0-experimental"
+	var systemFiles []types.File
+
+	systemFiles = append(systemFiles, types.File{
+		Node: types.Node{
+			Name:      "50-base.ign",
+			Directory: "base.d",
+		},
+		Contents: string(deletingBaseConfig),
+	}, types.File{
+		Node: types.Node{
+			Name:      "50-override.ign",
+			Directory: "override.d",
+		},
+		Contents: string(deletingOverrideConfig),
+	})
+	out[0].Partitions.AddFiles("ROOT", []types.File{
+		{
+			Node: types.Node{
+				Name:      "base2",
+				Directory: "foo",
+			},
+			Contents: "base config\n",
+		},
+		{
+			Node: types.Node{
+				Name:      "user1",
+				Directory: "foo",
+			},
+			Contents: "user config\n",
+		},
+	})
+	out[0].Partitions.AddRemovedNodes("ROOT", []types.Node{
+		{
+			Name:      "base1",
+			Directory: "foo",
+		},
+		{
+			Name:      "user2",
+			Directory: "foo",
+		},
+	})
+	return types.Test{
+		Name:             name,
+		In:               in,
+		Out:              out,
+		Config:           config,
+		SystemDirFiles:   systemFiles,
+		ConfigMinVersion: configMinVersion,
+	}
+}
+
+// VerifyDeleteInheritedFromLaterFragment checks if an override fragment
+// can delete an entry of the user config which an earlier override
+// fragment doesn't have.
+func VerifyDeleteInheritedFromLaterFragment() types.Test {
+	name := "merge.delete.inherited.fragments"
+	in := types.GetBaseDisk()
+	out := types.GetBaseDisk()
+	config := `{
+		"ignition": {"version": "$version"},
+		"storage": {
+			"files": [{
+				"path": "/foo/user1",
+				"contents": { "source": "data:,user%20config%0A" }
+			},
+			{
+				"path": "/foo/user2",
+				"contents": { "source": "data:,user%20config%0A" }
+			}]
+		}
+	}`
+	configMinVersion := "3.0.0"
+	var systemFiles []types.File
+
+	systemFiles = append(systemFiles, types.File{
+		Node: types.Node{
+			Name:      "10-first.ign",
+			Directory: "override.d",
+		},
+		Contents: string(firstOverrideFragment),
+	}, types.File{
+		Node: types.Node{
+			Name:      "20-second.ign",
+			Directory: "override.d",
+		},
+		Contents: string(secondOverrideFragment),
+	})
+	out[0].Partitions.AddFiles("ROOT", []types.File{
+		{
+			Node: types.Node{
+				Name:      "override1",
+				Directory: "foo",
+			},
+			Contents: "override config\n",
+		},
+		{
+			Node: types.Node{
+				Name:      "user2",
+				Directory: "foo",
+			},
+			Contents: "user config\n",
+		},
+	})
+	out[0].Partitions.AddRemovedNodes("ROOT", []types.Node{
+		{
+			Name:      "user1",
+			Directory: "foo",
+		},
+	})
+	return types.Test{
+		Name:             name,
+		In:               in,
+		Out:              out,
+		Config:           config,
+		SystemDirFiles:   systemFiles,
+		ConfigMinVersion: configMinVersion,
+	}
+}
+
+// VerifyDeleteInheritedFromMergedConfig checks if a config merged into the
+// user config deletes an entry of both the user config and the base config.
+func VerifyDeleteInheritedFromMergedConfig() types.Test {
+	name := "merge.delete.inherited.merged"
+	in := types.GetBaseDisk()
+	out := types.GetBaseDisk()
+	config := strings.Replace(`{
+		"ignition": {
+			"version": "$version",
+			"config": {
+				"merge": [{
+					"source": "data:;base64,MERGED"
+				}]
+			}
+		},
+		"storage": {
+			"files": [{
+				"path": "/foo/user1",
+				"contents": { "source": "data:,user%20config%0A" }
+			}]
+		},
+		"systemd": {
+			"units": [{
+				"name": "deleted.service",
+				"contents": "[Unit]\nDescription=user config"
+			}]
+		}
+	}`, "MERGED", base64.StdEncoding.EncodeToString(deletingMergedConfig), 1)
+	configMinVersion := "3.0.0"
+	var systemFiles []types.File
+
+	systemFiles = append(systemFiles, types.File{
+		Node: types.Node{
+			Name:      "50-base.ign",
+			Directory: "base.d",
+		},
+		Contents: string(unitBaseConfig),
+	})
+	out[0].Partitions.AddFiles("ROOT", []types.File{
+		{
+			Node: types.Node{
+				Name:      "user1",
+				Directory: "foo",
+			},
+			Contents: "user config\n",
+		},
+	})
+	out[0].Partitions.AddRemovedNodes("ROOT", []types.Node{
+		{
+			Name:      "deleted.service",
+			Directory: "etc/systemd/system",
+		},
+	})
+	return types.Test{
+		Name:             name,
+		In:               in,
+		Out:              out,
+		Config:           config,
+		SystemDirFiles:   systemFiles,
+		ConfigMinVersion: configMinVersion,
+	}
+}
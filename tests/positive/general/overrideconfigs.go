@@ -0,0 +1,118 @@
+// Copyright 2026 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package general
+
+import (
+	"github.com/coreos/ignition/v2/tests/register"
+	"github.com/coreos/ignition/v2/tests/types"
+)
+
+func init() {
+	register.Register(register.PositiveTest, VerifyOverrideConfigsTakePrecedence())
+}
+
+var (
+	overrideConfig = []byte(`{
+				"ignition": { "version": "3.0.0" },
+				"storage": {
+					"files": [{
+						"path": "/foo/bar",
+						"contents": { "source": "data:,override%20config%0A" }
+					}]
+				}
+			}`)
+	overridePlatformConfig = []byte(`{
+				"ignition": { "version": "3.0.0" },
+				"storage": {
+					"files": [{
+						"path": "/foo/bar2",
+						"contents": { "source": "data:,override%20platform%20config%0A" }
+					}]
+				}
+			}`)
+)
+
+// VerifyOverrideConfigsTakePrecedence checks if override configs
+// and/or platform override configs are merged on top of the user
+// config.
+func VerifyOverrideConfigsTakePrecedence() types.Test {
+	name := "merge.override.configs"
+	in := types.GetBaseDisk()
+	out := types.GetBaseDisk()
+	config := `{
+		"ignition": {"version": "$version"},
+		"storage": {
+			"files": [{
+				"path": "/foo/bar",
+				"contents": { "source": "data:,user%20config%0A" }
+			},
+			{
+				"path": "/foo/bar2",
+				"contents": { "source": "data:,user%20config%0A" }
+			},
+			{
+				"path": "/foo/bar3",
+				"contents": { "source": "data:,user%20config%0A" }
+			}]
+		}
+	}`
+	configMinVersion := "3.0.0"
+	var systemFiles []types.File
+
+	systemFiles = append(systemFiles, types.File{
+		Node: types.Node{
+			Name:      "50-override.ign",
+			Directory: "override.d",
+		},
+		Contents: string(overrideConfig),
+	}, types.File{
+		Node: types.Node{
+			Name:      "50-override.ign",
+			Directory: "override.platform.d/file",
+		},
+		Contents: string(overridePlatformConfig),
+	})
+	out[0].Partitions.AddFiles("ROOT", []types.File{
+		{
+			Node: types.Node{
+				Name:      "bar",
+				Directory: "foo",
+			},
+			Contents: "override config\n",
+		},
+		{
+			Node: types.Node{
+				Name:      "bar2",
+				Directory: "foo",
+			},
+			Contents: "override platform config\n",
+		},
+		{
+			Node: types.Node{
+				Name:      "bar3",
+				Directory: "foo",
+			},
+			Contents: "user config\n",
+		},
+	})
+	return types.Test{
+		Name:             name,
+		In:               in,
+		Out:              out,
+		Config:           config,
+		SystemDirFiles:   systemFiles,
+		ConfigMinVersion: configMinVersion,
+	}
+}
@@ -106,6 +106,7 @@ type Test struct {
 	ConfigVersion     string
 	ConfigShouldBeBad bool // Set to true to skip config validation step
 	SkipCriticalCheck bool // Set to true to skip critical logging check
+	FetchOffline      bool // Set to true to run the fetch-offline stage first, as the initramfs does
 }
 
 func (ps Partitions) GetPartition(label string) *Partition {